@@ -0,0 +1,555 @@
+package main
+
+import (
+	"6502emulator/emulator"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+)
+
+// debugger is the command line started by --debug.
+type debugger struct {
+	cpu *emulator.CPU
+	bus *emulator.Bus
+
+	symbols map[string]uint16 // Labels by name, from --symbols
+	labels  map[uint16]string // Labels by address
+
+	in   *bufio.Scanner
+	out  io.Writer
+	last string // The last command, repeated by an empty line
+}
+
+const debuggerHelp = `Numbers are hex, addresses can also be labels from --symbols.
+  s, step [n]           execute n instructions (default 1)
+  n, next               execute an instruction, running JSRs until they return
+  finish                run until the current subroutine returns
+  c, continue           run until a breakpoint, or ctrl-C
+  back [n]              undo n instructions (default 1)
+  b, break <addr>       set a breakpoint
+  d, delete <addr>      delete a breakpoint
+  breaks                list the breakpoints
+  r, regs               show the registers and flags
+  set <reg> <value>     set A, X, Y, SP, PC, P, or a flag C, Z, I, D, V, N (0 or 1)
+  x <addr> [n]          hex dump n bytes (default 64)
+  poke <addr> <byte>... write bytes to memory
+  l, dis [addr] [n]     disassemble n instructions (default around the PC)
+  stack                 show the stack
+  q, quit               exit`
+
+// The number of steps the back command can undo.
+const debuggerHistory = 100000
+
+func newDebugger(cpu *emulator.CPU, bus *emulator.Bus, symbols map[string]uint16, in io.Reader, out io.Writer) *debugger {
+	d := &debugger{
+		cpu:     cpu,
+		bus:     bus,
+		symbols: symbols,
+		labels:  map[uint16]string{},
+		in:      bufio.NewScanner(in),
+		out:     out,
+	}
+
+	for name, address := range symbols {
+		// Prefer the shortest name when several labels share an address, it's usually the public one
+		if label, ok := d.labels[address]; !ok || len(name) < len(label) || len(name) == len(label) && name < label {
+			d.labels[address] = name
+		}
+	}
+
+	cpu.SetHistory(debuggerHistory)
+
+	return d
+}
+
+// run reads commands until quit or the end of the input.
+func (d *debugger) run() {
+	d.showLocation()
+
+	for {
+		fmt.Fprint(d.out, "(6502) ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			return
+		}
+
+		line := strings.TrimSpace(d.in.Text())
+		if line == "" {
+			line = d.last
+		}
+		d.last = line
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "q" || fields[0] == "quit" {
+			return
+		}
+
+		if err := d.command(fields[0], fields[1:]); err != nil {
+			fmt.Fprintln(d.out, err)
+		}
+	}
+}
+
+func (d *debugger) command(name string, args []string) error {
+	switch name {
+	case "h", "help":
+		fmt.Fprintln(d.out, debuggerHelp)
+	case "s", "step":
+		n, err := d.count(args, 1)
+		if err != nil {
+			return err
+		}
+		d.step(n)
+	case "n", "next":
+		d.next()
+	case "finish":
+		d.finish()
+	case "c", "continue":
+		d.resume(func(ctx context.Context) (emulator.StopReason, error) {
+			return d.cpu.Run(ctx)
+		})
+	case "back":
+		n, err := d.count(args, 1)
+		if err != nil {
+			return err
+		}
+		if undone := d.cpu.StepBack(n); undone < n {
+			fmt.Fprintf(d.out, "only %d steps in the history\n", undone)
+		}
+		d.showLocation()
+	case "b", "break":
+		address, err := d.argAddress(args)
+		if err != nil {
+			return err
+		}
+		d.cpu.AddBreakpoint(address)
+		fmt.Fprintf(d.out, "breakpoint at %s\n", d.name(address))
+	case "d", "delete":
+		address, err := d.argAddress(args)
+		if err != nil {
+			return err
+		}
+		d.cpu.RemoveBreakpoint(address)
+	case "breaks":
+		for _, address := range d.cpu.Breakpoints() {
+			fmt.Fprintln(d.out, d.name(address))
+		}
+	case "r", "regs":
+		d.showRegisters()
+	case "set":
+		return d.set(args)
+	case "x":
+		return d.dump(args)
+	case "poke":
+		return d.poke(args)
+	case "l", "dis":
+		return d.disassemble(args)
+	case "stack":
+		d.showStack()
+	default:
+		return fmt.Errorf("unknown command %q, try help", name)
+	}
+
+	return nil
+}
+
+// resume runs the CPU until run returns, ctrl-C stops it.
+func (d *debugger) resume(run func(ctx context.Context) (emulator.StopReason, error)) emulator.StopReason {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	reason, err := run(ctx)
+	switch reason {
+	case emulator.StopBreakpoint:
+		// RunUntil stops the same way at the address it was asked to run to
+		if pc := d.cpu.State().PC; d.cpu.HasBreakpoint(pc) {
+			fmt.Fprintf(d.out, "breakpoint at %s\n", d.name(pc))
+		}
+	case emulator.StopCancelled:
+		fmt.Fprintln(d.out, "interrupted")
+	case emulator.StopHalted:
+		fmt.Fprintln(d.out, "halted")
+	case emulator.StopError:
+		fmt.Fprintln(d.out, err)
+	}
+	d.showLocation()
+
+	return reason
+}
+
+func (d *debugger) step(n int) {
+	d.resume(func(ctx context.Context) (emulator.StopReason, error) {
+		return d.cpu.RunInstructions(ctx, uint64(n))
+	})
+}
+
+func (d *debugger) next() {
+	pc := d.cpu.State().PC
+	if d.cpu.Disassemble(pc).OpCode.Instruction != emulator.JSR {
+		d.step(1)
+		return
+	}
+
+	d.resume(func(ctx context.Context) (emulator.StopReason, error) {
+		return d.cpu.RunUntil(ctx, pc+3)
+	})
+}
+
+func (d *debugger) finish() {
+	sp := d.cpu.State().SP
+
+	// The RTS that returns from this subroutine is the first one with the stack pointer back where it was,
+	// the run stops once it has executed
+	returning := func() bool {
+		state := d.cpu.State()
+		return d.cpu.Disassemble(state.PC).OpCode.Instruction == emulator.RTS && state.SP >= sp
+	}
+	returned := returning()
+
+	d.resume(func(ctx context.Context) (emulator.StopReason, error) {
+		return d.cpu.RunUntilFunc(ctx, func(cpu *emulator.CPU) bool {
+			if returned {
+				return true
+			}
+			returned = returning()
+			return false
+		})
+	})
+}
+
+func (d *debugger) showLocation() {
+	d.showInstruction(d.cpu.Disassemble(d.cpu.State().PC))
+}
+
+func (d *debugger) showInstruction(instruction emulator.Disassembly) {
+	if label, ok := d.labels[instruction.Address]; ok {
+		fmt.Fprintf(d.out, "%s:\n", label)
+	}
+
+	marker := "  "
+	if instruction.Address == d.cpu.State().PC {
+		marker = "=>"
+	}
+	if d.cpu.HasBreakpoint(instruction.Address) {
+		marker = marker[:1] + "*"
+	}
+
+	line := fmt.Sprintf("%s %04X  %-8s  %s", marker, instruction.Address, instruction.HexBytes(), instruction)
+	if label, ok := d.labels[instruction.Target]; ok && instruction.HasTarget {
+		line = fmt.Sprintf("%-36s; %s", line, label)
+	}
+	fmt.Fprintln(d.out, line)
+}
+
+func (d *debugger) showRegisters() {
+	state := d.cpu.State()
+
+	flags := []byte("nv-bdizc")
+	for i := range flags {
+		if state.P&(0x80>>i) != 0 && flags[i] != '-' {
+			flags[i] -= 'a' - 'A'
+		}
+	}
+
+	fmt.Fprintf(d.out, "PC=%04X A=%02X X=%02X Y=%02X SP=%02X P=%02X [%s] cycles=%d\n",
+		state.PC, state.A, state.X, state.Y, state.SP, state.P, flags, state.Cycles)
+}
+
+func (d *debugger) set(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: set <reg> <value>")
+	}
+
+	state := d.cpu.State()
+	register := strings.ToUpper(args[0])
+
+	if register == "PC" {
+		address, err := d.address(args[1])
+		if err != nil {
+			return err
+		}
+		state.PC = address
+		d.cpu.SetState(state)
+		d.showLocation()
+		return nil
+	}
+
+	value, err := parseByte(args[1])
+	if err != nil {
+		return err
+	}
+
+	flags := map[string]uint8{"C": 0x01, "Z": 0x02, "I": 0x04, "D": 0x08, "V": 0x40, "N": 0x80}
+	switch register {
+	case "A":
+		state.A = value
+	case "X":
+		state.X = value
+	case "Y":
+		state.Y = value
+	case "SP":
+		state.SP = value
+	case "P":
+		state.P = value
+	default:
+		flag, ok := flags[register]
+		if !ok {
+			return fmt.Errorf("unknown register %q", args[0])
+		}
+		if value != 0 {
+			state.P |= flag
+		} else {
+			state.P &^= flag
+		}
+	}
+
+	d.cpu.SetState(state)
+	d.showRegisters()
+	return nil
+}
+
+func (d *debugger) dump(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: x <addr> [n]")
+	}
+
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+	n, err := d.count(args[1:], 64)
+	if err != nil {
+		return err
+	}
+
+	for line := 0; line < n; line += 16 {
+		start := address + uint16(line)
+		var hex, text strings.Builder
+		for i := 0; i < 16 && line+i < n; i++ {
+			b := d.bus.Peek(start + uint16(i))
+			fmt.Fprintf(&hex, "%02X ", b)
+			if b >= 0x20 && b < 0x7F {
+				text.WriteByte(b)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(d.out, "%04X  %-48s %s\n", start, hex.String(), text.String())
+	}
+
+	return nil
+}
+
+func (d *debugger) poke(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: poke <addr> <byte>...")
+	}
+
+	address, err := d.address(args[0])
+	if err != nil {
+		return err
+	}
+
+	var data []uint8
+	for _, arg := range args[1:] {
+		b, err := parseByte(arg)
+		if err != nil {
+			return err
+		}
+		data = append(data, b)
+	}
+
+	for i, b := range data {
+		d.bus.Poke(address+uint16(i), b)
+	}
+
+	return nil
+}
+
+func (d *debugger) disassemble(args []string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("usage: dis [addr] [n]")
+	}
+
+	var count []string
+	if len(args) == 2 {
+		count = args[1:]
+	}
+	n, err := d.count(count, 10)
+	if err != nil {
+		return err
+	}
+
+	var address uint16
+	if len(args) == 0 {
+		// Show a few instructions before the program counter too
+		address = d.backtrack(d.cpu.State().PC, n/3)
+	} else if address, err = d.address(args[0]); err != nil {
+		return err
+	}
+
+	for i := 0; i < n; i++ {
+		instruction := d.cpu.Disassemble(address)
+		d.showInstruction(instruction)
+		address += uint16(len(instruction.Bytes))
+	}
+
+	return nil
+}
+
+// backtrack finds an address up to n instructions before pc that disassembles into a sequence ending at pc.
+// Instructions have different lengths, so this is a guess, pc itself is returned if there is none.
+func (d *debugger) backtrack(pc uint16, n int) uint16 {
+	for back := n * 3; back > 0; back-- {
+		address := pc - uint16(back)
+		remaining := back
+		instructions := 0
+
+		for remaining > 0 {
+			size := len(d.cpu.Disassemble(address).Bytes)
+			address += uint16(size)
+			remaining -= size
+			instructions++
+		}
+
+		if remaining == 0 && instructions <= n {
+			return pc - uint16(back)
+		}
+	}
+
+	return pc
+}
+
+// showStack prints the stack from the top down, marking the bytes that look like a return address pushed by JSR.
+func (d *debugger) showStack() {
+	sp := d.cpu.State().SP
+	if sp == 0xFF {
+		fmt.Fprintln(d.out, "stack is empty")
+		return
+	}
+
+	for address := 0x0100 + uint16(sp) + 1; address <= 0x01FF; address++ {
+		line := fmt.Sprintf("%04X  %02X", address, d.bus.Peek(address))
+
+		if address < 0x01FF {
+			ret := (uint16(d.bus.Peek(address)) | uint16(d.bus.Peek(address+1))<<8) + 1
+			if d.bus.Peek(ret-3) == 0x20 {
+				line += fmt.Sprintf("  return to %s", d.name(ret))
+			}
+		}
+
+		fmt.Fprintln(d.out, line)
+	}
+}
+
+// count parses an optional count argument.
+func (d *debugger) count(args []string, fallback int) (int, error) {
+	if len(args) == 0 {
+		return fallback, nil
+	}
+
+	n, err := strconv.ParseUint(strings.TrimPrefix(args[0], "$"), 16, 16)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid count %q", args[0])
+	}
+
+	return int(n), nil
+}
+
+func (d *debugger) argAddress(args []string) (uint16, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected an address")
+	}
+
+	return d.address(args[0])
+}
+
+// address parses a label, or a hex address with an optional $ or 0x prefix.
+func (d *debugger) address(arg string) (uint16, error) {
+	if address, ok := d.symbols[arg]; ok {
+		return address, nil
+	}
+
+	address, err := strconv.ParseUint(trimHexPrefix(arg), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %q", arg)
+	}
+
+	return uint16(address), nil
+}
+
+// name formats an address with its label, if it has one.
+func (d *debugger) name(address uint16) string {
+	if label, ok := d.labels[address]; ok {
+		return fmt.Sprintf("$%04X (%s)", address, label)
+	}
+
+	return fmt.Sprintf("$%04X", address)
+}
+
+func parseByte(arg string) (uint8, error) {
+	b, err := strconv.ParseUint(trimHexPrefix(arg), 16, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte %q", arg)
+	}
+
+	return uint8(b), nil
+}
+
+func trimHexPrefix(s string) string {
+	s = strings.TrimPrefix(s, "$")
+	s = strings.TrimPrefix(s, "0x")
+	return s
+}
+
+// loadSymbols reads the labels for the debugger. Every line with a name and a hex address is a label, in either
+// order, so "name = $8100", "main: 8100" and "$8100 name" all work. Other lines are skipped.
+func loadSymbols(r io.Reader) (map[string]uint16, error) {
+	symbols := map[string]uint16{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(strings.Replace(scanner.Text(), "=", " ", 1))
+		if len(fields) != 2 {
+			continue
+		}
+
+		// The address is the field with a prefix, or the only one that is a hex number
+		index := -1
+		for i, field := range fields {
+			if strings.HasPrefix(field, "$") || strings.HasPrefix(field, "0x") {
+				index = i
+			}
+		}
+		if index < 0 {
+			_, err0 := strconv.ParseUint(fields[0], 16, 16)
+			_, err1 := strconv.ParseUint(fields[1], 16, 16)
+			switch {
+			case err0 == nil && err1 != nil:
+				index = 0
+			case err1 == nil && err0 != nil:
+				index = 1
+			default:
+				continue
+			}
+		}
+
+		address, err := strconv.ParseUint(trimHexPrefix(fields[index]), 16, 16)
+		if err != nil {
+			continue
+		}
+
+		symbols[strings.TrimSuffix(fields[1-index], ":")] = uint16(address)
+	}
+
+	return symbols, scanner.Err()
+}
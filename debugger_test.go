@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"6502emulator/emulator"
+)
+
+func TestLoadSymbols(t *testing.T) {
+	symbols, err := loadSymbols(strings.NewReader(`main = $8100
+loop: 8105
+$8110 done
+0x8120 other
+; a comment
+beef cafe
+name zz
+too many fields 1234
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]uint16{"main": 0x8100, "loop": 0x8105, "done": 0x8110, "other": 0x8120}
+	if len(symbols) != len(want) {
+		t.Errorf("got %v, want %v", symbols, want)
+	}
+	for name, address := range want {
+		if got, ok := symbols[name]; !ok || got != address {
+			t.Errorf("%s: got $%04X, want $%04X", name, got, address)
+		}
+	}
+}
+
+// newDebuggerTestCPU loads a program that calls a subroutine, which calls another one, at $0200.
+func newDebuggerTestCPU() (*emulator.CPU, *emulator.Bus) {
+	bus := &emulator.Bus{}
+	bus.AddMemory(emulator.NewRAM(0x1000, 0))
+	for address, data := range map[uint16][]uint8{
+		0x0200: {0x20, 0x00, 0x03}, // JSR sub
+		0x0203: {0xE8},             // INX
+		0x0204: {0x85, 0x10},       // STA $10
+		0x0206: {0x4C, 0x06, 0x02}, // JMP $0206
+		0x0300: {0x20, 0x10, 0x03}, // sub: JSR inner
+		0x0303: {0x60},             // RTS
+		0x0310: {0xA9, 0x42},       // inner: LDA #$42
+		0x0312: {0x60},             // RTS
+	} {
+		for i, b := range data {
+			bus.Write(address+uint16(i), b)
+		}
+	}
+
+	cpu := emulator.NewCPU()
+	cpu.ConnectBus(bus)
+	cpu.SetState(emulator.State{PC: 0x0200, SP: 0xFF, P: 0x20})
+
+	return cpu, bus
+}
+
+func TestDebuggerSession(t *testing.T) {
+	cpu, bus := newDebuggerTestCPU()
+	symbols := map[string]uint16{"sub": 0x0300, "inner": 0x0310}
+
+	var out bytes.Buffer
+	script := strings.Join([]string{
+		"b 0204",
+		"s",      // Into sub
+		"finish", // Through inner, back from sub
+		"c",      // To the breakpoint
+		"back 2", // Before the RTS from sub
+		"",       // back 2 again, before the LDA in inner
+		"r",
+		"q",
+	}, "\n")
+	newDebugger(cpu, bus, symbols, strings.NewReader(script), &out).run()
+
+	for _, want := range []string{
+		"breakpoint at $0204\n",
+		"sub:\n=> 0300  20 10 03  JSR $0310",
+		"=> 0203  E8        INX",
+		"=* 0204  85 10     STA $10",
+		"=> 0303  60        RTS",
+		"inner:\n=> 0310  A9 42     LDA #$42",
+		"PC=0310 A=00 X=00 Y=00 SP=FB",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("the output doesn't contain %q:\n%s", want, out.String())
+		}
+	}
+
+	if state := cpu.State(); state.PC != 0x0310 || state.SP != 0xFB || state.X != 0 || state.A != 0 {
+		t.Errorf("got PC=%04X SP=%02X X=%02X A=%02X, want the start of inner", state.PC, state.SP, state.X, state.A)
+	}
+	if data := bus.Peek(0x0010); data != 0 {
+		t.Errorf("got $%02X at $10, want the STA not run", data)
+	}
+}
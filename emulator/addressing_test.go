@@ -0,0 +1,90 @@
+package emulator
+
+import "testing"
+
+func TestZeroPageWrap(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant Variant
+		program []uint8
+		x, y    uint8
+		memory  map[uint16]uint8
+	}{
+		{"($FF,X) with X=0", MOS6502, []uint8{0xA1, 0xFF}, 0x00, 0x00,
+			map[uint16]uint8{0x00FF: 0x34, 0x0000: 0x12, 0x0100: 0x56, 0x1234: 0x42}},
+		{"($80,X) with X=$7F", MOS6502, []uint8{0xA1, 0x80}, 0x7F, 0x00,
+			map[uint16]uint8{0x00FF: 0x34, 0x0000: 0x12, 0x0100: 0x56, 0x1234: 0x42}},
+		{"($FF),Y", MOS6502, []uint8{0xB1, 0xFF}, 0x00, 0x01,
+			map[uint16]uint8{0x00FF: 0x34, 0x0000: 0x12, 0x0100: 0x56, 0x1235: 0x42}},
+		{"$FF,X", MOS6502, []uint8{0xB5, 0xFF}, 0x02, 0x00,
+			map[uint16]uint8{0x0001: 0x42, 0x0101: 0x56}},
+		{"$80,X with X=$FF", MOS6502, []uint8{0xB5, 0x80}, 0xFF, 0x00,
+			map[uint16]uint8{0x007F: 0x42, 0x017F: 0x56}},
+		{"($FF,X) on the 65C02", WDC65C02, []uint8{0xA1, 0xFF}, 0x00, 0x00,
+			map[uint16]uint8{0x00FF: 0x34, 0x0000: 0x12, 0x0100: 0x56, 0x1234: 0x42}},
+		{"($FF),Y on the 65C02", WDC65C02, []uint8{0xB1, 0xFF}, 0x00, 0x01,
+			map[uint16]uint8{0x00FF: 0x34, 0x0000: 0x12, 0x0100: 0x56, 0x1235: 0x42}},
+		{"$FF,X on the 65C02", WDC65C02, []uint8{0xB5, 0xFF}, 0x02, 0x00,
+			map[uint16]uint8{0x0001: 0x42, 0x0101: 0x56}},
+		{"($FF) on the 65C02", WDC65C02, []uint8{0xB2, 0xFF}, 0x00, 0x00,
+			map[uint16]uint8{0x00FF: 0x34, 0x0000: 0x12, 0x0100: 0x56, 0x1234: 0x42}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, ram := newTestCPU(t, test.variant, test.program...)
+			for address, data := range test.memory {
+				ram[address] = data
+			}
+			cpu.registers.X = test.x
+			cpu.registers.Y = test.y
+
+			step(t, cpu)
+
+			if a := cpu.State().A; a != 0x42 {
+				t.Errorf("got A=$%02X, want $42", a)
+			}
+		})
+	}
+}
+
+func TestZeroPageYWrap(t *testing.T) {
+	// LDX $FF,Y
+	cpu, ram := newTestCPU(t, MOS6502, 0xB6, 0xFF)
+	ram[0x0001] = 0x42
+	ram[0x0101] = 0x56
+	cpu.registers.Y = 0x02
+
+	step(t, cpu)
+
+	if x := cpu.State().X; x != 0x42 {
+		t.Errorf("got X=$%02X, want $42", x)
+	}
+}
+
+func TestJMPIndirectPageWrap(t *testing.T) {
+	tests := []struct {
+		variant Variant
+		pc      uint16
+		cycles  uint64
+	}{
+		// The NMOS 6502 takes the high byte from the start of the same page
+		{MOS6502, 0x1234, 5},
+		// The 65C02 carries into the high byte of the vector address, and takes a cycle more
+		{WDC65C02, 0x5634, 6},
+	}
+
+	for _, test := range tests {
+		// JMP ($10FF)
+		cpu, ram := newTestCPU(t, test.variant, 0x6C, 0xFF, 0x10)
+		ram[0x10FF] = 0x34
+		ram[0x1000] = 0x12
+		ram[0x1100] = 0x56
+
+		step(t, cpu)
+
+		if state := cpu.State(); state.PC != test.pc || state.Cycles != test.cycles {
+			t.Errorf("%v: got PC=$%04X after %d cycles, want $%04X after %d", test.variant, state.PC, state.Cycles, test.pc, test.cycles)
+		}
+	}
+}
@@ -5,14 +5,65 @@ package emulator
 // the address bus is used to specify the location of data
 // the data bus is used to transfer data between the CPU and memory
 
+// Memory is a device on the bus, it answers for the addresses it Contains.
+// The bus caches which memories answer for each 256 byte page, so Contains must keep giving the same answers
+// until Invalidate is called on the bus. A memory that moves, like a bank switched ROM, has to call Invalidate
+// after it changes what it Contains.
 type Memory interface {
 	Read(address uint16) uint8
 	Write(address uint16, data uint8)
 	Contains(address uint16) bool
 }
 
+// DebugMemory is implemented by memories that can be read and written without side effects, for debuggers and
+// the history. Memories whose reads or writes have side effects, like I/O ports, should implement it: the bus
+// peeks and pokes the others with Read and Write, and the history doesn't undo writes to them.
+type DebugMemory interface {
+	Peek(address uint16) uint8
+	Poke(address uint16, data uint8)
+}
+
+// Bus connects the CPU to its memories. An access goes to every memory that Contains the address,
+// the results of a read are ORed together.
+//
+// The memories that answer for each page are cached. The cache is rebuilt after AddMemory, after Invalidate,
+// and when the length of Memory changes. Replacing an entry of Memory in place, or a memory changing what
+// it Contains, is not noticed until Invalidate is called: until then accesses keep going to the old layout.
 type Bus struct {
+	// Memory lists the memories on the bus, add to it with AddMemory.
+	// Call Invalidate after replacing an entry, or when what a memory Contains changes, like on a bank switch.
 	Memory []Memory
+
+	// pages caches which memories contain at least one address of each 256 byte page, so an access only
+	// has to ask the memories that could contain it. It is rebuilt after AddMemory and Invalidate.
+	pages       [256][]Memory
+	pagesMemory int
+}
+
+// page returns the memories that may contain the address.
+func (bus *Bus) page(address uint16) []Memory {
+	if bus.pagesMemory != len(bus.Memory) {
+		bus.buildPages()
+	}
+
+	return bus.pages[address>>8]
+}
+
+func (bus *Bus) buildPages() {
+	for page := range bus.pages {
+		bus.pages[page] = nil
+
+		for _, memory := range bus.Memory {
+			for offset := 0; offset < 0x100; offset++ {
+				if memory.Contains(uint16(page<<8 | offset)) {
+					bus.pages[page] = append(bus.pages[page], memory)
+					break
+				}
+			}
+		}
+	}
+
+	bus.pagesMemory = len(bus.Memory)
 }
 
 func (bus *Bus) Read(address uint16) uint8 {
@@ -21,7 +72,7 @@ func (bus *Bus) Read(address uint16) uint8 {
 	}
 
 	var result uint8
-	for _, memory := range bus.Memory {
+	for _, memory := range bus.page(address) {
 		if memory.Contains(address) {
 			result |= memory.Read(address)
 		}
@@ -39,7 +90,7 @@ func (bus *Bus) Write(address uint16, data uint8) {
 
 	// fmt.Printf("W %04x %02x\n", address, data)
 
-	for _, memory := range bus.Memory {
+	for _, memory := range bus.page(address) {
 		if memory.Contains(address) {
 			memory.Write(address, data)
 		}
@@ -48,4 +99,75 @@ func (bus *Bus) Write(address uint16, data uint8) {
 
 func (bus *Bus) AddMemory(memory Memory) {
 	bus.Memory = append(bus.Memory, memory)
+	bus.Invalidate()
+}
+
+// Invalidate drops the cached memory layout, the next access asks every memory what it Contains again.
+func (bus *Bus) Invalidate() {
+	bus.pagesMemory = -1
+}
+
+// Peek reads like Read, for debuggers. Memories that implement DebugMemory are read with their Peek,
+// the others with Read, so it is only free of side effects when every memory at the address implements it.
+func (bus *Bus) Peek(address uint16) uint8 {
+	if bus == nil {
+		return 0
+	}
+
+	var result uint8
+	for _, memory := range bus.page(address) {
+		if !memory.Contains(address) {
+			continue
+		}
+
+		if debug, ok := memory.(DebugMemory); ok {
+			result |= debug.Peek(address)
+		} else {
+			result |= memory.Read(address)
+		}
+	}
+
+	return result
+}
+
+// peekDebug reads like Peek, ok is false if a memory at the address doesn't implement DebugMemory
+// and reading it could have side effects.
+func (bus *Bus) peekDebug(address uint16) (data uint8, ok bool) {
+	if bus == nil {
+		return 0, false
+	}
+
+	for _, memory := range bus.page(address) {
+		if !memory.Contains(address) {
+			continue
+		}
+
+		debug, ok := memory.(DebugMemory)
+		if !ok {
+			return 0, false
+		}
+		data |= debug.Peek(address)
+	}
+
+	return data, true
+}
+
+// Poke writes like Write, for debuggers. Memories that implement DebugMemory are written with their Poke,
+// the others with Write.
+func (bus *Bus) Poke(address uint16, data uint8) {
+	if bus == nil {
+		return
+	}
+
+	for _, memory := range bus.page(address) {
+		if !memory.Contains(address) {
+			continue
+		}
+
+		if debug, ok := memory.(DebugMemory); ok {
+			debug.Poke(address, data)
+		} else {
+			memory.Write(address, data)
+		}
+	}
 }
@@ -0,0 +1,54 @@
+package emulator
+
+import "testing"
+
+func TestBusInvalidate(t *testing.T) {
+	low := &iRAM{Data: make([]uint8, 0x100), Offset: 0x0000}
+	high := &iRAM{Data: make([]uint8, 0x100), Offset: 0x1000}
+
+	bus := &Bus{}
+	bus.AddMemory(low)
+	bus.Write(0x0010, 0x42)
+
+	// Bank switch the RAM to $1000, the bus keeps the old layout until it is invalidated
+	low.Offset = 0x1000
+	if got := bus.Read(0x1010); got != 0x00 {
+		t.Errorf("got $%02X before invalidating, want the stale layout to read nothing", got)
+	}
+	bus.Invalidate()
+	if got := bus.Read(0x1010); got != 0x42 {
+		t.Errorf("got $%02X after moving the memory, want $42", got)
+	}
+
+	// Replace the entry
+	bus.Memory[0] = high
+	bus.Invalidate()
+	bus.Write(0x1010, 0x24)
+	if high.Data[0x10] != 0x24 || low.Data[0x10] != 0x42 {
+		t.Errorf("write went to the replaced memory")
+	}
+}
+
+func TestBusPageCache(t *testing.T) {
+	// Two memories sharing page $20
+	first := &iRAM{Data: make([]uint8, 0x80), Offset: 0x2000}
+	second := &iRAM{Data: make([]uint8, 0x80), Offset: 0x2080}
+
+	bus := &Bus{}
+	bus.AddMemory(first)
+	bus.Write(0x2010, 0x11)
+
+	// Appending to Memory directly is noticed by the length changing
+	bus.Memory = append(bus.Memory, second)
+	bus.Write(0x2090, 0x22)
+
+	if first.Data[0x10] != 0x11 || second.Data[0x10] != 0x22 {
+		t.Errorf("got $%02X and $%02X, want each write in its own memory", first.Data[0x10], second.Data[0x10])
+	}
+	if got := bus.Read(0x2090); got != 0x22 {
+		t.Errorf("got $%02X from the second half of the page, want $22", got)
+	}
+	if got := bus.Read(0x2110); got != 0x00 {
+		t.Errorf("got $%02X from an empty page, want $00", got)
+	}
+}
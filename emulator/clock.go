@@ -0,0 +1,101 @@
+package emulator
+
+import (
+	"context"
+	"time"
+)
+
+// Clock paces the CPU, it is consulted by the Run methods before every instruction.
+// A CPU without a clock runs as fast as it can.
+type Clock interface {
+	// Sync blocks until the CPU may run the next instruction, cycles is the CPU's current cycle count.
+	Sync(ctx context.Context, cycles uint64) error
+}
+
+// RealTimeClock runs the CPU at a fixed frequency.
+// A time.Ticker can't deliver MHz rates, so instead the CPU runs a batch of cycles at full speed and then sleeps
+// until wall clock time catches up. The deadline is always computed from the time the clock started, so rounding
+// errors in the sleeps don't add up to drift. When the CPU falls behind it runs without sleeping until it has caught up,
+// unless it falls more than MaxLag behind (a debugger pause, a suspended process), then the backlog is dropped.
+type RealTimeClock struct {
+	Frequency float64       // Cycles per second
+	Batch     uint64        // The number of cycles to run between syncs
+	MaxLag    time.Duration // How far behind the clock may fall before it gives up catching up
+
+	start       time.Time
+	startCycles uint64
+	next        uint64
+}
+
+// NewRealTimeClock returns a clock running at the given frequency in Hz, syncing every millisecond.
+func NewRealTimeClock(frequency float64) *RealTimeClock {
+	batch := uint64(frequency / 1000)
+	if batch == 0 {
+		batch = 1
+	}
+
+	return &RealTimeClock{
+		Frequency: frequency,
+		Batch:     batch,
+		MaxLag:    100 * time.Millisecond,
+	}
+}
+
+func (c *RealTimeClock) Sync(ctx context.Context, cycles uint64) error {
+	if c.start.IsZero() {
+		c.anchor(cycles)
+		return nil
+	}
+
+	if cycles < c.next {
+		return nil
+	}
+	c.next = cycles + c.Batch
+
+	deadline := c.start.Add(time.Duration(float64(cycles-c.startCycles) / c.Frequency * float64(time.Second)))
+
+	wait := time.Until(deadline)
+	if wait <= 0 {
+		if -wait > c.MaxLag {
+			c.anchor(cycles)
+		}
+
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// anchor restarts the schedule from the current time.
+func (c *RealTimeClock) anchor(cycles uint64) {
+	c.start = time.Now()
+	c.startCycles = cycles
+	c.next = cycles + c.Batch
+}
+
+// StepClock runs one instruction for every value received on C.
+type StepClock struct {
+	C <-chan struct{}
+}
+
+func (c StepClock) Sync(ctx context.Context, cycles uint64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.C:
+		return nil
+	}
+}
+
+// SetClock sets the clock the Run methods use to pace the CPU, nil runs the CPU as fast as possible.
+func (cpu *CPU) SetClock(clock Clock) {
+	cpu.pacer = clock
+}
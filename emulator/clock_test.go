@@ -0,0 +1,65 @@
+package emulator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStepClock(t *testing.T) {
+	steps := make(chan struct{})
+	clock := StepClock{C: steps}
+
+	done := make(chan error)
+	go func() {
+		done <- clock.Sync(context.Background(), 0)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Sync returned %v before a step arrived", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	steps <- struct{}{}
+	if err := <-done; err != nil {
+		t.Fatalf("got %v after a step, want nil", err)
+	}
+
+	// Cancelling the context ends the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- clock.Sync(ctx, 0)
+	}()
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v after cancelling, want context.Canceled", err)
+	}
+}
+
+func TestStepClockPacesRun(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502, runLoop...)
+	steps := make(chan struct{})
+	cpu.SetClock(StepClock{C: steps})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan StopReason)
+	go func() {
+		reason, _ := cpu.Run(ctx)
+		done <- reason
+	}()
+
+	for i := 0; i < 3; i++ {
+		steps <- struct{}{}
+	}
+	cancel()
+
+	if reason := <-done; reason != StopCancelled {
+		t.Errorf("got %v, want cancelled", reason)
+	}
+	// INX, JMP, INX
+	if x := cpu.State().X; x != 2 {
+		t.Errorf("got X=%d after 3 steps, want 2", x)
+	}
+}
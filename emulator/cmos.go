@@ -0,0 +1,262 @@
+package emulator
+
+// Variant selects which member of the 6502 family the CPU emulates.
+type Variant uint8
+
+const (
+	// MOS6502 is the original NMOS 6502
+	MOS6502 Variant = iota
+	// WDC65C02 is the WDC CMOS 65C02, including the Rockwell bit instructions and WAI/STP
+	WDC65C02
+)
+
+func (v Variant) String() string {
+	switch v {
+	case MOS6502:
+		return "6502"
+	case WDC65C02:
+		return "65C02"
+	}
+
+	panic("unreachable")
+}
+
+// lookup finds the opcode in the maps of the selected variant.
+func (cpu *CPU) lookup(opcode uint8) (OpCode, bool) {
+	if cpu.variant == WDC65C02 {
+		if opCode, ok := CMOSOpCodeMap[opcode]; ok {
+			return opCode, true
+		}
+
+		opCode, ok := OpCodeMap[opcode]
+		return opCode, ok
+	}
+
+	opCode, ok := OpCodeMap[opcode]
+	if !ok && cpu.undocumented {
+		opCode, ok = UndocumentedOpCodeMap[opcode]
+	}
+
+	return opCode, ok
+}
+
+// penalizesPageCross reports whether crossing a page costs the instruction an extra cycle.
+// The 65C02 shifts and rotates with absolute,X only take it when they cross, on the 6502 they always take 7 cycles.
+func (cpu *CPU) penalizesPageCross(opCode OpCode) bool {
+	if cpu.variant == WDC65C02 && opCode.MemoryMode == AbsoluteX {
+		switch opCode.Instruction {
+		case ASL, LSR, ROL, ROR:
+			return true
+		}
+	}
+
+	return opCode.Instruction.PageCrossPenalty()
+}
+
+// The opcodes the 65C02 adds or changes, the rest are the same as OpCodeMap.
+// Every opcode that is undefined on the 65C02 is a NOP, so together with OpCodeMap this covers all 256.
+var CMOSOpCodeMap = map[uint8]OpCode{
+	0x72: {ADC, ZeroPageIndirect, 5}, // ADC
+	0x32: {AND, ZeroPageIndirect, 5}, // AND
+	0xD2: {CMP, ZeroPageIndirect, 5}, // CMP
+	0x52: {EOR, ZeroPageIndirect, 5}, // EOR
+	0xB2: {LDA, ZeroPageIndirect, 5}, // LDA
+	0x12: {ORA, ZeroPageIndirect, 5}, // ORA
+	0xF2: {SBC, ZeroPageIndirect, 5}, // SBC
+	0x92: {STA, ZeroPageIndirect, 5}, // STA
+
+	0x89: {BIT, Immediate, 2}, // BIT
+	0x34: {BIT, ZeroPageX, 4}, // BIT
+	0x3C: {BIT, AbsoluteX, 4}, // BIT
+
+	0x3A: {DEC, Accumulator, 2}, // DEC
+	0x1A: {INC, Accumulator, 2}, // INC
+
+	0x6C: {JMP, Indirect, 6},                // JMP
+	0x7C: {JMP, AbsoluteIndexedIndirect, 6}, // JMP
+
+	0x80: {BRA, Relative, 2}, // BRA
+
+	0xDA: {PHX, Implicit, 3}, // PHX
+	0x5A: {PHY, Implicit, 3}, // PHY
+	0xFA: {PLX, Implicit, 4}, // PLX
+	0x7A: {PLY, Implicit, 4}, // PLY
+
+	0x64: {STZ, ZeroPage, 3},  // STZ
+	0x74: {STZ, ZeroPageX, 4}, // STZ
+	0x9C: {STZ, Absolute, 4},  // STZ
+	0x9E: {STZ, AbsoluteX, 5}, // STZ
+
+	0x1E: {ASL, AbsoluteX, 6}, // ASL
+	0x3E: {ROL, AbsoluteX, 6}, // ROL
+	0x5E: {LSR, AbsoluteX, 6}, // LSR
+	0x7E: {ROR, AbsoluteX, 6}, // ROR
+
+	0x14: {TRB, ZeroPage, 5}, // TRB
+	0x1C: {TRB, Absolute, 6}, // TRB
+
+	0x04: {TSB, ZeroPage, 5}, // TSB
+	0x0C: {TSB, Absolute, 6}, // TSB
+
+	0x07: {RMB, ZeroPage, 5}, // RMB0
+	0x17: {RMB, ZeroPage, 5}, // RMB1
+	0x27: {RMB, ZeroPage, 5}, // RMB2
+	0x37: {RMB, ZeroPage, 5}, // RMB3
+	0x47: {RMB, ZeroPage, 5}, // RMB4
+	0x57: {RMB, ZeroPage, 5}, // RMB5
+	0x67: {RMB, ZeroPage, 5}, // RMB6
+	0x77: {RMB, ZeroPage, 5}, // RMB7
+
+	0x87: {SMB, ZeroPage, 5}, // SMB0
+	0x97: {SMB, ZeroPage, 5}, // SMB1
+	0xA7: {SMB, ZeroPage, 5}, // SMB2
+	0xB7: {SMB, ZeroPage, 5}, // SMB3
+	0xC7: {SMB, ZeroPage, 5}, // SMB4
+	0xD7: {SMB, ZeroPage, 5}, // SMB5
+	0xE7: {SMB, ZeroPage, 5}, // SMB6
+	0xF7: {SMB, ZeroPage, 5}, // SMB7
+
+	0x0F: {BBR, ZeroPageRelative, 5}, // BBR0
+	0x1F: {BBR, ZeroPageRelative, 5}, // BBR1
+	0x2F: {BBR, ZeroPageRelative, 5}, // BBR2
+	0x3F: {BBR, ZeroPageRelative, 5}, // BBR3
+	0x4F: {BBR, ZeroPageRelative, 5}, // BBR4
+	0x5F: {BBR, ZeroPageRelative, 5}, // BBR5
+	0x6F: {BBR, ZeroPageRelative, 5}, // BBR6
+	0x7F: {BBR, ZeroPageRelative, 5}, // BBR7
+
+	0x8F: {BBS, ZeroPageRelative, 5}, // BBS0
+	0x9F: {BBS, ZeroPageRelative, 5}, // BBS1
+	0xAF: {BBS, ZeroPageRelative, 5}, // BBS2
+	0xBF: {BBS, ZeroPageRelative, 5}, // BBS3
+	0xCF: {BBS, ZeroPageRelative, 5}, // BBS4
+	0xDF: {BBS, ZeroPageRelative, 5}, // BBS5
+	0xEF: {BBS, ZeroPageRelative, 5}, // BBS6
+	0xFF: {BBS, ZeroPageRelative, 5}, // BBS7
+
+	0xCB: {WAI, Implicit, 3}, // WAI
+	0xDB: {STP, Implicit, 3}, // STP
+
+	0x02: {NOP, Immediate, 2}, // NOP
+	0x22: {NOP, Immediate, 2}, // NOP
+	0x42: {NOP, Immediate, 2}, // NOP
+	0x62: {NOP, Immediate, 2}, // NOP
+	0x82: {NOP, Immediate, 2}, // NOP
+	0xC2: {NOP, Immediate, 2}, // NOP
+	0xE2: {NOP, Immediate, 2}, // NOP
+	0x44: {NOP, ZeroPage, 3},  // NOP
+	0x54: {NOP, ZeroPageX, 4}, // NOP
+	0xD4: {NOP, ZeroPageX, 4}, // NOP
+	0xF4: {NOP, ZeroPageX, 4}, // NOP
+	0x5C: {NOP, Absolute, 8},  // NOP
+	0xDC: {NOP, Absolute, 4},  // NOP
+	0xFC: {NOP, Absolute, 4},  // NOP
+
+	0x03: {NOP, Implicit, 1}, // NOP
+	0x13: {NOP, Implicit, 1}, // NOP
+	0x23: {NOP, Implicit, 1}, // NOP
+	0x33: {NOP, Implicit, 1}, // NOP
+	0x43: {NOP, Implicit, 1}, // NOP
+	0x53: {NOP, Implicit, 1}, // NOP
+	0x63: {NOP, Implicit, 1}, // NOP
+	0x73: {NOP, Implicit, 1}, // NOP
+	0x83: {NOP, Implicit, 1}, // NOP
+	0x93: {NOP, Implicit, 1}, // NOP
+	0xA3: {NOP, Implicit, 1}, // NOP
+	0xB3: {NOP, Implicit, 1}, // NOP
+	0xC3: {NOP, Implicit, 1}, // NOP
+	0xD3: {NOP, Implicit, 1}, // NOP
+	0xE3: {NOP, Implicit, 1}, // NOP
+	0xF3: {NOP, Implicit, 1}, // NOP
+	0x0B: {NOP, Implicit, 1}, // NOP
+	0x1B: {NOP, Implicit, 1}, // NOP
+	0x2B: {NOP, Implicit, 1}, // NOP
+	0x3B: {NOP, Implicit, 1}, // NOP
+	0x4B: {NOP, Implicit, 1}, // NOP
+	0x5B: {NOP, Implicit, 1}, // NOP
+	0x6B: {NOP, Implicit, 1}, // NOP
+	0x7B: {NOP, Implicit, 1}, // NOP
+	0x8B: {NOP, Implicit, 1}, // NOP
+	0x9B: {NOP, Implicit, 1}, // NOP
+	0xAB: {NOP, Implicit, 1}, // NOP
+	0xBB: {NOP, Implicit, 1}, // NOP
+	0xEB: {NOP, Implicit, 1}, // NOP
+	0xFB: {NOP, Implicit, 1}, // NOP
+}
+
+func (i iInstructionSet) BBR(cpu *CPU, mode MemoryMode, bit uint8) {
+	val, _, _ := i.MemoryMode(cpu, mode, true)
+	i.branch(cpu, val&(1<<bit) == 0)
+}
+
+func (i iInstructionSet) BBS(cpu *CPU, mode MemoryMode, bit uint8) {
+	val, _, _ := i.MemoryMode(cpu, mode, true)
+	i.branch(cpu, val&(1<<bit) != 0)
+}
+
+func (i iInstructionSet) BRA(cpu *CPU) {
+	i.branch(cpu, true)
+}
+
+func (i iInstructionSet) PHX(cpu *CPU) {
+	cpu.PushToStack(cpu.registers.X)
+}
+
+func (i iInstructionSet) PHY(cpu *CPU) {
+	cpu.PushToStack(cpu.registers.Y)
+}
+
+func (i iInstructionSet) PLX(cpu *CPU) {
+	cpu.dummyRead(cpu.stackPointer.Address())
+	cpu.registers.X = cpu.PopFromStack()
+
+	cpu.flags.Zero = cpu.registers.X == 0
+	cpu.flags.Negative = cpu.registers.X&0x80 != 0
+}
+
+func (i iInstructionSet) PLY(cpu *CPU) {
+	cpu.dummyRead(cpu.stackPointer.Address())
+	cpu.registers.Y = cpu.PopFromStack()
+
+	cpu.flags.Zero = cpu.registers.Y == 0
+	cpu.flags.Negative = cpu.registers.Y&0x80 != 0
+}
+
+func (i iInstructionSet) RMB(cpu *CPU, mode MemoryMode, bit uint8) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+	cpu.write(addr, val&^(1<<bit))
+}
+
+func (i iInstructionSet) SMB(cpu *CPU, mode MemoryMode, bit uint8) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+	cpu.write(addr, val|1<<bit)
+}
+
+func (i iInstructionSet) STP(cpu *CPU) {
+	// Stop the clock until the next reset
+	cpu.halted = true
+}
+
+func (i iInstructionSet) STZ(cpu *CPU, mode MemoryMode) {
+	_, addr, _ := i.MemoryMode(cpu, mode, false)
+	cpu.write(addr, 0)
+}
+
+func (i iInstructionSet) TRB(cpu *CPU, mode MemoryMode) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+
+	cpu.flags.Zero = val&cpu.registers.A == 0
+	cpu.write(addr, val&^cpu.registers.A)
+}
+
+func (i iInstructionSet) TSB(cpu *CPU, mode MemoryMode) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+
+	cpu.flags.Zero = val&cpu.registers.A == 0
+	cpu.write(addr, val|cpu.registers.A)
+}
+
+func (i iInstructionSet) WAI(cpu *CPU) {
+	// Sleep until the next interrupt
+	cpu.waiting = true
+}
@@ -0,0 +1,124 @@
+package emulator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCMOSInstructions(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []uint8
+		before  State
+		memory  map[uint16]uint8
+		want    State // PC, A, X, Y and P are compared
+		written map[uint16]uint8
+		cycles  uint64
+	}{
+		{"BRA", []uint8{0x80, 0x02}, State{}, nil, State{PC: 0x0204, P: p(0)}, nil, 3},
+		{"BRA across a page", []uint8{0x80, 0xF0}, State{}, nil, State{PC: 0x01F2, P: p(0)}, nil, 4},
+
+		{"STZ zp", []uint8{0x64, 0x10}, State{P: p(flagN)}, map[uint16]uint8{0x10: 0xFF}, State{PC: 0x0202, P: p(flagN)}, map[uint16]uint8{0x10: 0x00}, 3},
+		{"STZ zp,X", []uint8{0x74, 0x10}, State{X: 0x01}, map[uint16]uint8{0x11: 0xFF}, State{PC: 0x0202, X: 0x01, P: p(0)}, map[uint16]uint8{0x11: 0x00}, 4},
+		{"STZ abs", []uint8{0x9C, 0x00, 0x10}, State{}, map[uint16]uint8{0x1000: 0xFF}, State{PC: 0x0203, P: p(0)}, map[uint16]uint8{0x1000: 0x00}, 4},
+		{"STZ abs,X", []uint8{0x9E, 0x00, 0x10}, State{X: 0x01}, map[uint16]uint8{0x1001: 0xFF}, State{PC: 0x0203, X: 0x01, P: p(0)}, map[uint16]uint8{0x1001: 0x00}, 5},
+
+		{"TSB zp sets Z", []uint8{0x04, 0x10}, State{A: 0x0F}, map[uint16]uint8{0x10: 0xF0}, State{PC: 0x0202, A: 0x0F, P: p(flagZ)}, map[uint16]uint8{0x10: 0xFF}, 5},
+		{"TSB abs clears Z", []uint8{0x0C, 0x00, 0x10}, State{A: 0x0F, P: p(flagZ)}, map[uint16]uint8{0x1000: 0x01}, State{PC: 0x0203, A: 0x0F, P: p(0)}, map[uint16]uint8{0x1000: 0x0F}, 6},
+		{"TRB zp clears Z", []uint8{0x14, 0x10}, State{A: 0x0F, P: p(flagZ)}, map[uint16]uint8{0x10: 0x3F}, State{PC: 0x0202, A: 0x0F, P: p(0)}, map[uint16]uint8{0x10: 0x30}, 5},
+		{"TRB abs sets Z", []uint8{0x1C, 0x00, 0x10}, State{A: 0x0F}, map[uint16]uint8{0x1000: 0xF0}, State{PC: 0x0203, A: 0x0F, P: p(flagZ)}, map[uint16]uint8{0x1000: 0xF0}, 6},
+
+		{"BBR0 taken", []uint8{0x0F, 0x10, 0x02}, State{}, map[uint16]uint8{0x10: 0xFE}, State{PC: 0x0205, P: p(0)}, nil, 6},
+		{"BBR0 not taken", []uint8{0x0F, 0x10, 0x02}, State{}, map[uint16]uint8{0x10: 0x01}, State{PC: 0x0203, P: p(0)}, nil, 5},
+		{"BBS7 taken", []uint8{0xFF, 0x10, 0x02}, State{}, map[uint16]uint8{0x10: 0x80}, State{PC: 0x0205, P: p(0)}, nil, 6},
+		{"BBS7 not taken", []uint8{0xFF, 0x10, 0x02}, State{}, map[uint16]uint8{0x10: 0x7F}, State{PC: 0x0203, P: p(0)}, nil, 5},
+
+		{"RMB3", []uint8{0x37, 0x10}, State{}, map[uint16]uint8{0x10: 0xFF}, State{PC: 0x0202, P: p(0)}, map[uint16]uint8{0x10: 0xF7}, 5},
+		{"SMB3", []uint8{0xB7, 0x10}, State{}, map[uint16]uint8{0x10: 0x00}, State{PC: 0x0202, P: p(0)}, map[uint16]uint8{0x10: 0x08}, 5},
+
+		{"JMP (abs,X)", []uint8{0x7C, 0x00, 0x10}, State{X: 0x02}, map[uint16]uint8{0x1002: 0x34, 0x1003: 0x12}, State{PC: 0x1234, X: 0x02, P: p(0)}, nil, 6},
+
+		{"BIT # only sets Z", []uint8{0x89, 0xC0}, State{A: 0x00}, nil, State{PC: 0x0202, P: p(flagZ)}, nil, 2},
+		{"BIT # leaves N and V", []uint8{0x89, 0x00}, State{A: 0xFF, P: p(flagN | flagV)}, nil, State{PC: 0x0202, A: 0xFF, P: p(flagN | flagV | flagZ)}, nil, 2},
+
+		{"INC A", []uint8{0x1A}, State{A: 0xFF}, nil, State{PC: 0x0201, A: 0x00, P: p(flagZ)}, nil, 2},
+		{"DEC A", []uint8{0x3A}, State{A: 0x00}, nil, State{PC: 0x0201, A: 0xFF, P: p(flagN)}, nil, 2},
+
+		{"WAI", []uint8{0xCB}, State{}, nil, State{PC: 0x0201, P: p(0)}, nil, 3},
+		{"STP", []uint8{0xDB}, State{}, nil, State{PC: 0x0201, P: p(0)}, nil, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, ram := newTestCPU(t, WDC65C02, test.program...)
+			for address, data := range test.memory {
+				ram[address] = data
+			}
+
+			before := test.before
+			before.PC = 0x0200
+			before.SP = 0xFF
+			if before.P == 0 {
+				before.P = p(0)
+			}
+			cpu.SetState(before)
+
+			step(t, cpu)
+
+			got := cpu.State()
+			want := test.want
+			if got.PC != want.PC || got.A != want.A || got.X != want.X || got.Y != want.Y || got.P != want.P {
+				t.Errorf("got PC=%04X A=%02X X=%02X Y=%02X P=%08b, want PC=%04X A=%02X X=%02X Y=%02X P=%08b",
+					got.PC, got.A, got.X, got.Y, got.P, want.PC, want.A, want.X, want.Y, want.P)
+			}
+			for address, data := range test.written {
+				if ram[address] != data {
+					t.Errorf("got $%02X at $%04X, want $%02X", ram[address], address, data)
+				}
+			}
+			if got.Cycles != test.cycles {
+				t.Errorf("took %d cycles, want %d", got.Cycles, test.cycles)
+			}
+		})
+	}
+}
+
+func TestWAI(t *testing.T) {
+	// WAI, INX
+	cpu, _ := newTestCPU(t, WDC65C02, 0xCB, 0xE8)
+	cpu.SetState(State{PC: 0x0200, SP: 0xFF, P: p(flagI)})
+	irq := cpu.IRQ().NewSource()
+
+	step(t, cpu)
+	for i := 0; i < 3; i++ {
+		step(t, cpu)
+	}
+	if state := cpu.State(); state.PC != 0x0201 || state.X != 0 {
+		t.Fatalf("got PC=%04X X=%02X while waiting, want the CPU to wait at $0201", state.PC, state.X)
+	}
+
+	// A masked IRQ ends the wait without being serviced
+	irq.Assert()
+	step(t, cpu)
+	step(t, cpu)
+	if state := cpu.State(); state.PC != 0x0202 || state.X != 1 || state.SP != 0xFF {
+		t.Errorf("got PC=%04X X=%02X SP=%02X after the IRQ, want INX to run without servicing the IRQ", state.PC, state.X, state.SP)
+	}
+}
+
+func TestSTP(t *testing.T) {
+	cpu, ram := newTestCPU(t, WDC65C02, 0xDB, 0xE8)
+	ram[0xFFFC], ram[0xFFFD] = 0x01, 0x02
+
+	step(t, cpu)
+	if err := cpu.Step(); !errors.Is(err, ErrHalted) {
+		t.Fatalf("got %v after STP, want ErrHalted", err)
+	}
+
+	// Only a reset starts it again
+	cpu.Reset()
+	step(t, cpu)
+	if state := cpu.State(); state.PC != 0x0202 || state.X != 1 {
+		t.Errorf("got PC=%04X X=%02X after the reset, want INX to run", state.PC, state.X)
+	}
+}
@@ -1,7 +1,9 @@
 package emulator
 
 import (
-	"fmt"
+	"context"
+	"io"
+	"math/rand"
 	"time"
 )
 
@@ -18,109 +20,365 @@ type CPU struct {
 	cycleCount         uint64 // The number of cycles that have passed
 	previousCycleCount uint64 // The number of cycles that have passed in the previous step
 
+	pageCrossed      bool   // Whether the current instruction's indexed address crossed a page boundary
+	extraCycles      int    // Cycles the current instruction takes on top of its base cycle count
+	readModifyWrite  bool   // Whether the current instruction writes its operand back
+	pageCrossPenalty bool   // Whether crossing a page costs the current instruction an extra cycle
+	instructionStart uint64 // The cycle count when the current instruction was fetched
+
+	cycleAccurate bool          // Whether every cycle is a bus access, see SetCycleAccurate
+	ticks         chan struct{} // Receives a value for every cycle while driven by Tick
+	tocks         chan error    // Answers every tick once the cycle is done
+	cycleOpen     bool          // Set when the tick being held has been used by a cycle
+	ticksClosed   bool          // Set once StopTicking has closed ticks, the instruction in progress runs to the end
+
 	flags Flags
 
-	clock     <-chan time.Time
-	bus       *Bus
-	interrupt <-chan struct{}
+	clock           <-chan time.Time // Pulsed once per cycle, if set
+	pacer           Clock            // Consulted before every instruction by the Run methods, if set
+	bus             *Bus
+	interruptChan   <-chan struct{}
+	interruptSource *InterruptSource // The IRQ source driven by interruptChan
+	inputChan       <-chan uint8
+	inputPort       InputPort // Where the bytes from inputChan go
+
+	events    eventQueue // Live external events, applied before the next instruction
+	recording io.Writer  // The external events are logged here, if set
+	replaying []Event    // The recorded events still to be applied
+	replay    bool       // Set when the events come from replaying instead of the live channels
+
+	irq   InterruptLine
+	nmi   InterruptLine
+	reset InterruptLine
+	ready InterruptLine // RDY, stalls the CPU while asserted
+	so    InterruptLine // SO, sets the overflow flag when asserted
+
+	irqPending              bool // Set when the IRQ line was asserted and unmasked at the last poll
+	nmiPending              bool // Set when the NMI line had an edge at the last poll
+	resetHeld               bool // Set while the RESET line is asserted
+	stalled                 bool // Set when the last step was a RDY stall and ran no instruction
+	delayedInterruptDisable bool // Set by CLI, SEI and PLP, the new I flag is only seen by the poll after the next instruction
 
 	instructionSet iInstructionSet
+
+	undocumented        bool                // Whether the undocumented NMOS opcodes are decoded
+	unknownOpcodePolicy UnknownOpcodePolicy // What to do with opcodes that can't be decoded
+	halted              bool                // Set by a JAM or STP, cleared by a reset
+	waiting             bool                // Set by WAI, cleared by an interrupt
+	variant             Variant
+
+	powerOnState  State      // The registers PowerOn starts with
+	powerOnRandom *rand.Rand // If set, PowerOn starts with random registers instead
+
+	observers []Observer
+	history   *history // Undo records of the last instructions, see SetHistory
+
+	breakpoints  *[0x10000]bool      // The addresses the Run methods stop at, see AddBreakpoint
+	watchpoints  *[0x10000]WatchKind // The accesses the Run methods stop after, see AddWatchpoint
+	watchHit     bool                // Set when the current instruction hit a watchpoint
+	watchAddress uint16
+	watchKind    WatchKind
+
+	decodeTable [256]decodedOpCode // The opcodes of the current variant, see buildDecodeTable
 }
 
+// Reset runs the 6502 reset sequence, it takes 7 cycles.
+// The sequence is an interrupt with the bus writes suppressed, so the stack pointer is decremented by 3
+// without anything being pushed. The I flag is set, and the program counter is read from the reset vector.
+// A, X, Y and the other flags keep whatever value they had.
 func (cpu *CPU) Reset() {
-	cpu.programCounter = uint16(cpu.bus.Read(0xFFFC)) | uint16(cpu.bus.Read(0xFFFD))<<8
-	cpu.stackPointer = 0
-	cpu.registers.A = 0
-	cpu.registers.X = 0
-	cpu.registers.Y = 0
+	cpu.halted = false
+	cpu.waiting = false
+	cpu.irqPending = false
+	cpu.nmiPending = false
 
-	cpu.flags.FromByte(0)
+	start := cpu.cycleCount
+	cpu.dummyRead(cpu.programCounter)
+	cpu.dummyRead(cpu.programCounter)
+	for i := 0; i < 3; i++ {
+		// The pushes turn into reads
+		cpu.dummyRead(cpu.stackPointer.Address())
+		cpu.stackPointer.Push()
+	}
+
+	cpu.flags.InterruptDisable = true
+	// The 65C02 also leaves decimal mode
+	if cpu.variant == WDC65C02 {
+		cpu.flags.Decimal = false
+	}
+
+	cpu.programCounter = uint16(cpu.read(ResetVector)) | uint16(cpu.read(ResetVector+1))<<8
+
+	cpu.spend(start, 7)
 }
 
-func (cpu *CPU) Start() {
+// PowerOn loads the power-on register values and runs the reset sequence.
+// A real 6502 powers on with undefined registers, see SetPowerOnState and RandomizePowerOnState.
+func (cpu *CPU) PowerOn() {
+	state := cpu.powerOnState
+	if cpu.powerOnRandom != nil {
+		state = State{
+			SP: uint8(cpu.powerOnRandom.Intn(0x100)),
+			A:  uint8(cpu.powerOnRandom.Intn(0x100)),
+			X:  uint8(cpu.powerOnRandom.Intn(0x100)),
+			Y:  uint8(cpu.powerOnRandom.Intn(0x100)),
+			P:  uint8(cpu.powerOnRandom.Intn(0x100)),
+		}
+	}
+
+	// Bit 5 always reads as 1 and B doesn't exist in the register
+	state.P = (state.P | 0x20) &^ 0x10
+	state.PC = 0
+	state.Cycles = 0
+	cpu.SetState(state)
+
 	cpu.Reset()
+}
 
-	for {
-		select {
-		case <-cpu.interrupt:
-			if cpu.flags.InterruptDisable {
-				continue
-			}
-
-			cpu.Interrupt()
-		case <-cpu.clock:
-			cpu.cycleCount++
-			cpu.Step()
-		}
+// SetPowerOnState sets the register values PowerOn starts with, PC and Cycles are ignored.
+// The default is all zero, which gives SP $FD after the reset sequence.
+func (cpu *CPU) SetPowerOnState(state State) {
+	cpu.powerOnState = state
+	cpu.powerOnRandom = nil
+}
+
+// RandomizePowerOnState makes PowerOn start with random register values, to catch programs that rely on uninitialized state.
+// The same seed gives the same values.
+func (cpu *CPU) RandomizePowerOnState(seed int64) {
+	cpu.powerOnRandom = rand.New(rand.NewSource(seed))
+}
+
+// Start powers on the CPU and runs it until Step returns an error.
+func (cpu *CPU) Start() error {
+	cpu.PowerOn()
+
+	_, err := cpu.Run(context.Background())
+	return err
+}
+
+// Step executes a single instruction, or services a pending interrupt.
+func (cpu *CPU) Step() error {
+	if cpu.history == nil {
+		return cpu.step()
 	}
+
+	cpu.history.begin(cpu)
+	err := cpu.step()
+	cpu.history.commit()
+
+	return err
 }
 
-func (cpu *CPU) Step() {
-	code := cpu.bus.Read(cpu.programCounter)
+func (cpu *CPU) step() error {
+	cpu.stalled = false
+
+	if err := cpu.applyEvents(); err != nil {
+		return err
+	}
+
+	if cpu.reset.Asserted() {
+		cpu.resetHeld = true
+		cpu.cycles(1)
+		return nil
+	}
+
+	if cpu.resetHeld {
+		cpu.resetHeld = false
+		cpu.Reset()
+		return nil
+	}
+
+	if cpu.halted {
+		return ErrHalted
+	}
+
+	if cpu.so.takeEdge() {
+		cpu.flags.Overflow = true
+	}
+
+	// The opcode fetch is a read cycle, a stalled CPU keeps repeating it.
+	// In cycle accurate mode the fetch stalls by itself.
+	if cpu.ready.Asserted() && !cpu.cycleAccurate {
+		cpu.cycles(1)
+		cpu.stalled = true
+		return nil
+	}
+
+	if cpu.nmiPending {
+		cpu.nmiPending = false
+		cpu.serviceInterrupt(NMIVector)
+		return nil
+	}
+
+	if cpu.irqPending {
+		cpu.irqPending = false
+		cpu.serviceInterrupt(IRQVector)
+		return nil
+	}
+
+	if cpu.waiting {
+		cpu.cycles(1)
+		cpu.pollInterrupts(cpu.flags.InterruptDisable)
+		return nil
+	}
+
+	interruptDisable := cpu.flags.InterruptDisable
+
+	pc := cpu.programCounter
+	cycle := cpu.cycleCount
+	cpu.instructionStart = cycle
+
+	code := cpu.fetch(cpu.programCounter)
 	cpu.programCounter++
 
-	cpu.ProcessInstruction(code)
+	if err := cpu.ProcessInstruction(code); err != nil {
+		return err
+	}
+
+	if len(cpu.observers) != 0 {
+		opCode, _ := cpu.decode(code)
+		for _, observer := range cpu.observers {
+			observer.OnInstruction(cpu, InstructionEvent{
+				PC:     pc,
+				Opcode: code,
+				OpCode: opCode,
+				Cycles: cpu.cycleCount - cycle,
+			})
+		}
+	}
+
+	if !cpu.delayedInterruptDisable {
+		interruptDisable = cpu.flags.InterruptDisable
+	}
+	cpu.pollInterrupts(interruptDisable)
+
+	return nil
 }
 
 func (cpu *CPU) ConnectBus(bus *Bus) {
 	cpu.bus = bus
 }
 
+// ConnectClock connects a channel the CPU waits on for every cycle, see SetClock for running at a given frequency.
 func (cpu *CPU) ConnectClock(clock <-chan time.Time) {
 	cpu.clock = clock
 }
 
+// ConnectInterrupt connects a channel that raises an IRQ for every value received on it while the CPU is running.
+// The request is held on the IRQ line until the CPU services it.
 func (cpu *CPU) ConnectInterrupt(interrupt <-chan struct{}) {
-	cpu.interrupt = interrupt
+	cpu.interruptChan = interrupt
+	cpu.interruptSource = cpu.irq.NewSource()
 }
 
+// RDY is the ready input, while it is asserted (pulled low) the CPU stalls on read cycles, for DMA or single stepping.
+// The stalled cycles are counted and wait for the clock like any other. The check happens at every opcode fetch, and in
+// cycle accurate mode at every read, the 65C02 also stalls on writes.
+func (cpu *CPU) RDY() *InterruptLine {
+	return &cpu.ready
+}
+
+// SO is the set overflow input, asserting it (a falling edge on the pin) sets the V flag.
+// It is sampled before every instruction.
+func (cpu *CPU) SO() *InterruptLine {
+	return &cpu.so
+}
+
+// Interrupt runs the IRQ sequence right away, regardless of the I flag.
 func (cpu *CPU) Interrupt() {
+	cpu.interrupt(IRQVector, false)
+}
+
+// interrupt pushes the return address and the flags, and jumps through the vector.
+// brk sets the B flag in the pushed flags, which is how a handler tells a BRK apart from an IRQ.
+func (cpu *CPU) interrupt(vector uint16, brk bool) {
+	returnAddress := cpu.programCounter
+
 	// Write the program counter to the stack
 	cpu.PushStack16(cpu.programCounter)
 	// Write the flags to the stack
-	cpu.PushToStack(cpu.flags.ToByte())
+	cpu.PushToStack(cpu.flags.StackByte(brk))
 
 	// Set the interrupt disable flag so that we don't get interrupted while handling the interrupt
 	cpu.flags.InterruptDisable = true
+	// The 65C02 also leaves decimal mode
+	if cpu.variant == WDC65C02 {
+		cpu.flags.Decimal = false
+	}
+	cpu.waiting = false
 
 	// Little-endian
-	// Read the program counter from the interrupt vector (0xFFFE) and (0xFFFF)
-	cpu.programCounter = uint16(cpu.bus.Read(0xFFFE)) | uint16(cpu.bus.Read(0xFFFF))<<8
+	// Read the program counter from the interrupt vector
+	cpu.programCounter = uint16(cpu.read(vector)) | uint16(cpu.read(vector+1))<<8
 
-	fmt.Println("Number of cycles passed: ", cpu.cycleCount, " (", cpu.cycleCount-cpu.previousCycleCount, ")")
+	for _, observer := range cpu.observers {
+		observer.OnInterrupt(cpu, InterruptEvent{
+			Vector:  vector,
+			BRK:     brk,
+			Return:  returnAddress,
+			Cycle:   cpu.cycleCount,
+			Elapsed: cpu.cycleCount - cpu.previousCycleCount,
+		})
+	}
 	cpu.previousCycleCount = cpu.cycleCount
 }
 
 func (cpu *CPU) Pulse() {
-	<-cpu.clock
+	if cpu.ticks != nil {
+		cpu.waitTick()
+	}
+	if cpu.clock != nil {
+		<-cpu.clock
+	}
 	cpu.cycleCount++
 }
 
 func (cpu *CPU) PushToStack(b uint8) {
-	cpu.bus.Write(cpu.stackPointer.Address(), b)
+	cpu.write(cpu.stackPointer.Address(), b)
 	cpu.stackPointer.Push()
 }
 
 func (cpu *CPU) PopFromStack() uint8 {
 	cpu.stackPointer.Pop()
-	return cpu.bus.Read(cpu.stackPointer.Address())
+	return cpu.read(cpu.stackPointer.Address())
 }
 
 func (cpu *CPU) PushStack16(b uint16) {
-	// Little endian
-	cpu.PushToStack(uint8(b))
+	// Little endian, the stack grows down so the high byte goes first
 	cpu.PushToStack(uint8(b >> 8))
+	cpu.PushToStack(uint8(b))
 }
 
 func (cpu *CPU) PopStack16() uint16 {
 	// Little endian
-	high := cpu.PopFromStack()
 	low := cpu.PopFromStack()
+	high := cpu.PopFromStack()
 	return uint16(high)<<8 | uint16(low)
 }
 
+// SetUndocumented enables or disables the undocumented NMOS opcodes, they are enabled by default.
+func (cpu *CPU) SetUndocumented(enabled bool) {
+	cpu.undocumented = enabled
+	cpu.buildDecodeTable()
+}
+
+// SetUnknownOpcodePolicy sets what the CPU does with opcodes it can't decode, the default is UnknownOpcodeNOP.
+func (cpu *CPU) SetUnknownOpcodePolicy(policy UnknownOpcodePolicy) {
+	cpu.unknownOpcodePolicy = policy
+}
+
+// SetVariant selects the member of the 6502 family to emulate, the default is MOS6502.
+func (cpu *CPU) SetVariant(variant Variant) {
+	cpu.variant = variant
+	cpu.buildDecodeTable()
+}
+
 func NewCPU() *CPU {
-	cpu := &CPU{}
+	cpu := &CPU{
+		undocumented: true,
+	}
+	cpu.flags.Unused = true
+	cpu.buildDecodeTable()
 
 	return cpu
 }
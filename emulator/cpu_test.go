@@ -0,0 +1,141 @@
+package emulator
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// benchmarkLoop adds to A and stores it through X until X wraps, then starts over.
+var benchmarkLoop = []uint8{
+	0xA2, 0x00, // $0200 LDX #$00
+	0x69, 0x01, // $0202 ADC #$01
+	0x9D, 0x00, 0x03, // $0204 STA $0300,X
+	0xE8,       // $0207 INX
+	0xD0, 0xF8, // $0208 BNE $0202
+	0x4C, 0x00, 0x02, // $020A JMP $0200
+}
+
+func reportInstructionsPerSecond(b *testing.B, elapsed time.Duration) {
+	b.ReportMetric(float64(b.N)/elapsed.Seconds(), "instructions/s")
+}
+
+func BenchmarkStep(b *testing.B) {
+	cpu, _ := newTestCPU(b, MOS6502, benchmarkLoop...)
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		if err := cpu.Step(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+
+	reportInstructionsPerSecond(b, time.Since(start))
+}
+
+func BenchmarkRun(b *testing.B) {
+	cpu, _ := newTestCPU(b, MOS6502, benchmarkLoop...)
+
+	b.ResetTimer()
+	start := time.Now()
+	if _, err := cpu.RunInstructions(context.Background(), uint64(b.N)); err != nil {
+		b.Fatal(err)
+	}
+	b.StopTimer()
+
+	reportInstructionsPerSecond(b, time.Since(start))
+}
+
+// writeCounter counts the bus writes the CPU makes.
+type writeCounter struct {
+	NopObserver
+	writes int
+}
+
+func (c *writeCounter) OnBusAccess(cpu *CPU, access BusAccess) {
+	if access.Write {
+		c.writes++
+	}
+}
+
+func TestReset(t *testing.T) {
+	tests := []struct {
+		variant Variant
+		p       uint8
+	}{
+		{MOS6502, p(flagI | flagD | flagC)},
+		{WDC65C02, p(flagI | flagC)},
+	}
+
+	for _, test := range tests {
+		cpu, ram := newTestCPU(t, test.variant)
+		ram[0xFFFC], ram[0xFFFD] = 0x34, 0x12
+		cpu.SetState(State{PC: 0x0200, SP: 0xFF, A: 0x11, X: 0x22, Y: 0x33, P: p(flagD | flagC)})
+
+		counter := &writeCounter{}
+		cpu.AddObserver(counter)
+
+		cpu.Reset()
+
+		want := State{PC: 0x1234, SP: 0xFC, A: 0x11, X: 0x22, Y: 0x33, P: test.p, Cycles: 7}
+		if got := cpu.State(); got != want {
+			t.Errorf("%v: got %+v after the reset, want %+v", test.variant, got, want)
+		}
+		if counter.writes != 0 {
+			t.Errorf("%v: the reset made %d writes", test.variant, counter.writes)
+		}
+	}
+}
+
+func TestPowerOnState(t *testing.T) {
+	cpu, ram := newTestCPU(t, MOS6502)
+	ram[0xFFFC], ram[0xFFFD] = 0x34, 0x12
+
+	cpu.SetPowerOnState(State{PC: 0x5555, SP: 0x80, A: 0x11, X: 0x22, Y: 0x33, P: p(flagD | flagC), Cycles: 99})
+	cpu.PowerOn()
+
+	// PC and Cycles come from the reset sequence, which also pulls SP down by 3 and sets I
+	want := State{PC: 0x1234, SP: 0x7D, A: 0x11, X: 0x22, Y: 0x33, P: p(flagI | flagD | flagC), Cycles: 7}
+	if got := cpu.State(); got != want {
+		t.Errorf("got %+v after PowerOn, want %+v", got, want)
+	}
+}
+
+func TestRandomizePowerOnState(t *testing.T) {
+	powerOn := func(seed int64) []State {
+		cpu, ram := newTestCPU(t, MOS6502)
+		ram[0xFFFC], ram[0xFFFD] = 0x34, 0x12
+		cpu.RandomizePowerOnState(seed)
+
+		var states []State
+		for i := 0; i < 4; i++ {
+			cpu.PowerOn()
+			states = append(states, cpu.State())
+		}
+
+		return states
+	}
+
+	first, second, other := powerOn(1), powerOn(1), powerOn(2)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("power on %d: got %+v and %+v from the same seed", i, first[i], second[i])
+		}
+		if first[i].P&0x30 != 0x20 {
+			t.Errorf("power on %d: got P=$%02X, want bit 5 set and B clear", i, first[i].P)
+		}
+	}
+
+	same := true
+	for i := range first {
+		same = same && first[i] == other[i]
+	}
+	if same {
+		t.Error("seeds 1 and 2 gave the same power on states")
+	}
+	if first[0] == first[1] && first[1] == first[2] {
+		t.Error("every power on gave the same state")
+	}
+}
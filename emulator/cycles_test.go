@@ -0,0 +1,61 @@
+package emulator
+
+import "testing"
+
+func TestCycleCounts(t *testing.T) {
+	pointer := map[uint16]uint8{0x10: 0xF0, 0x11: 0x10} // ($10) is $10F0
+
+	tests := []struct {
+		name    string
+		program []uint8
+		before  State
+		memory  map[uint16]uint8
+		cycles  uint64
+	}{
+		// Branches
+		{"BNE not taken", []uint8{0xD0, 0x02}, State{P: p(flagZ)}, nil, 2},
+		{"BNE taken", []uint8{0xD0, 0x02}, State{}, nil, 3},
+		{"BNE taken across a page", []uint8{0xD0, 0xF0}, State{}, nil, 4},
+		{"BEQ taken backwards on the same page", []uint8{0xF0, 0xFE}, State{P: p(flagZ)}, nil, 3},
+
+		// Indexed reads take a cycle more when the index carries into the high byte
+		{"LDA abs,X", []uint8{0xBD, 0xF0, 0x10}, State{X: 0x0F}, nil, 4},
+		{"LDA abs,X across a page", []uint8{0xBD, 0xF0, 0x10}, State{X: 0x10}, nil, 5},
+		{"LDA abs,Y", []uint8{0xB9, 0xF0, 0x10}, State{Y: 0x0F}, nil, 4},
+		{"LDA abs,Y across a page", []uint8{0xB9, 0xF0, 0x10}, State{Y: 0x10}, nil, 5},
+		{"LDA (zp),Y", []uint8{0xB1, 0x10}, State{Y: 0x0F}, pointer, 5},
+		{"LDA (zp),Y across a page", []uint8{0xB1, 0x10}, State{Y: 0x10}, pointer, 6},
+		{"CMP abs,X across a page", []uint8{0xDD, 0xF0, 0x10}, State{X: 0x10}, nil, 5},
+		{"LDX abs,Y across a page", []uint8{0xBE, 0xF0, 0x10}, State{Y: 0x10}, nil, 5},
+
+		// Writes and read-modify-writes always take the extra cycle
+		{"STA abs,X", []uint8{0x9D, 0xF0, 0x10}, State{X: 0x0F}, nil, 5},
+		{"STA abs,X across a page", []uint8{0x9D, 0xF0, 0x10}, State{X: 0x10}, nil, 5},
+		{"STA (zp),Y", []uint8{0x91, 0x10}, State{Y: 0x0F}, pointer, 6},
+		{"INC abs,X", []uint8{0xFE, 0xF0, 0x10}, State{X: 0x0F}, nil, 7},
+	}
+
+	for _, test := range tests {
+		for _, accurate := range []bool{false, true} {
+			cpu, ram := newTestCPU(t, MOS6502, test.program...)
+			cpu.SetCycleAccurate(accurate)
+			for address, data := range test.memory {
+				ram[address] = data
+			}
+
+			before := test.before
+			before.PC = 0x0200
+			before.SP = 0xFF
+			if before.P == 0 {
+				before.P = p(0)
+			}
+			cpu.SetState(before)
+
+			step(t, cpu)
+
+			if got := cpu.State().Cycles; got != test.cycles {
+				t.Errorf("%s (cycle accurate %v): took %d cycles, want %d", test.name, accurate, got, test.cycles)
+			}
+		}
+	}
+}
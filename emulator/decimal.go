@@ -0,0 +1,112 @@
+package emulator
+
+// The NMOS 6502 performs BCD arithmetic with a binary adder and a decimal
+// adjust stage. The flags are not all taken from the final (adjusted) result:
+//   - ADC: Z comes from the binary sum, N and V come from the sum after the
+//     low nibble has been adjusted but before the high nibble is, C comes from
+//     the fully adjusted result.
+//   - SBC: N, V, Z and C all behave exactly as they do in binary mode, only the
+//     accumulator gets the decimal result.
+// Invalid BCD operands (nibbles A-F) produce the same results as the hardware.
+// See http://www.6502.org/tutorials/decimal_mode.html (Appendix A).
+
+func (i iInstructionSet) decimalADC(cpu *CPU, val uint8) {
+	a := int(cpu.registers.A)
+	b := int(val)
+	carry := 0
+	if cpu.flags.Carry {
+		carry = 1
+	}
+
+	// Z is taken from the plain binary sum
+	cpu.flags.Zero = uint8(a+b+carry) == 0
+
+	lo := (a & 0x0F) + (b & 0x0F) + carry
+	if lo >= 0x0A {
+		lo = ((lo + 0x06) & 0x0F) + 0x10
+	}
+
+	result := (a & 0xF0) + (b & 0xF0) + lo
+
+	// N and V are taken before the high nibble is adjusted
+	cpu.flags.Negative = result&0x80 != 0
+	cpu.flags.Overflow = ^(a^b)&(a^result)&0x80 != 0
+
+	if result >= 0xA0 {
+		result += 0x60
+	}
+
+	cpu.flags.Carry = result >= 0x100
+	cpu.registers.A = uint8(result)
+}
+
+func (i iInstructionSet) decimalSBC(cpu *CPU, val uint8) {
+	a := int(cpu.registers.A)
+	b := int(val)
+	borrow := 1
+	if cpu.flags.Carry {
+		borrow = 0
+	}
+
+	// All flags are the same as for a binary subtraction
+	binary := a - b - borrow
+	cpu.flags.Carry = binary >= 0
+	cpu.flags.Zero = uint8(binary) == 0
+	cpu.flags.Negative = binary&0x80 != 0
+	cpu.flags.Overflow = (a^b)&(a^binary)&0x80 != 0
+
+	lo := (a & 0x0F) - (b & 0x0F) - borrow
+	if lo < 0 {
+		lo = ((lo - 0x06) & 0x0F) - 0x10
+	}
+
+	result := (a & 0xF0) - (b & 0xF0) + lo
+	if result < 0 {
+		result -= 0x60
+	}
+
+	cpu.registers.A = uint8(result)
+}
+
+// The 65C02 fixed the flags in decimal mode, N and Z reflect the final result and V is the same as on the NMOS part.
+// Both instructions take an extra cycle to do so.
+
+func (i iInstructionSet) cmosDecimalADC(cpu *CPU, val uint8) {
+	i.decimalADC(cpu, val)
+
+	cpu.flags.Zero = cpu.registers.A == 0
+	cpu.flags.Negative = cpu.registers.A&0x80 != 0
+
+	cpu.extraCycles++
+}
+
+func (i iInstructionSet) cmosDecimalSBC(cpu *CPU, val uint8) {
+	a := int(cpu.registers.A)
+	b := int(val)
+	borrow := 1
+	if cpu.flags.Carry {
+		borrow = 0
+	}
+
+	// C and V are the same as for a binary subtraction
+	binary := a - b - borrow
+	cpu.flags.Carry = binary >= 0
+	cpu.flags.Overflow = (a^b)&(a^binary)&0x80 != 0
+
+	lo := (a & 0x0F) - (b & 0x0F) - borrow
+
+	result := binary
+	if result < 0 {
+		result -= 0x60
+	}
+	if lo < 0 {
+		result -= 0x06
+	}
+
+	cpu.registers.A = uint8(result)
+
+	cpu.flags.Zero = cpu.registers.A == 0
+	cpu.flags.Negative = cpu.registers.A&0x80 != 0
+
+	cpu.extraCycles++
+}
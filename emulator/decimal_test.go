@@ -0,0 +1,151 @@
+package emulator
+
+import "testing"
+
+// The expected results follow the sequences in Appendix A of http://www.6502.org/tutorials/decimal_mode.html,
+// written out step by step instead of reusing the emulator's code.
+
+const (
+	flagC = 0x01
+	flagZ = 0x02
+	flagI = 0x04
+	flagD = 0x08
+	flagB = 0x10
+	flagV = 0x40
+	flagN = 0x80
+)
+
+type decimalResult struct {
+	A          uint8
+	C, Z, N, V bool
+}
+
+// decimalADCReference is sequence 1 (A and C) and sequence 2 (N and V) of the appendix. Z comes from the binary sum
+// on the NMOS 6502, the 65C02 takes N and Z from the result.
+func decimalADCReference(a, b uint8, carry bool, variant Variant) decimalResult {
+	c := 0
+	if carry {
+		c = 1
+	}
+
+	// Sequence 1
+	al := int(a&0x0F) + int(b&0x0F) + c
+	if al >= 0x0A {
+		al = ((al + 0x06) & 0x0F) + 0x10
+	}
+	sum := int(a&0xF0) + int(b&0xF0) + al
+	if sum >= 0xA0 {
+		sum += 0x60
+	}
+
+	// Sequence 2, the high nibbles are signed
+	signed := int(int8(a&0xF0)) + int(int8(b&0xF0)) + al
+
+	result := decimalResult{
+		A: uint8(sum),
+		C: sum >= 0x100,
+		N: signed&0x80 != 0,
+		V: signed < -128 || signed > 127,
+		Z: uint8(int(a)+int(b)+c) == 0,
+	}
+
+	if variant == WDC65C02 {
+		result.N = result.A&0x80 != 0
+		result.Z = result.A == 0
+	}
+
+	return result
+}
+
+// decimalSBCReference is sequence 3 of the appendix for the NMOS 6502 and sequence 4 for the 65C02.
+// C and V are the binary ones on both, so are N and Z on the NMOS 6502.
+func decimalSBCReference(a, b uint8, carry bool, variant Variant) decimalResult {
+	c := 0
+	if carry {
+		c = 1
+	}
+
+	binary := int(a) - int(b) + c - 1
+	signed := int(int8(a)) - int(int8(b)) + c - 1
+
+	result := decimalResult{
+		C: binary >= 0,
+		V: signed < -128 || signed > 127,
+		N: binary&0x80 != 0,
+		Z: uint8(binary) == 0,
+	}
+
+	al := int(a&0x0F) - int(b&0x0F) + c - 1
+	if variant == WDC65C02 {
+		// Sequence 4
+		difference := binary
+		if difference < 0 {
+			difference -= 0x60
+		}
+		if al < 0 {
+			difference -= 0x06
+		}
+
+		result.A = uint8(difference)
+		result.N = result.A&0x80 != 0
+		result.Z = result.A == 0
+		return result
+	}
+
+	// Sequence 3
+	if al < 0 {
+		al = ((al - 0x06) & 0x0F) - 0x10
+	}
+	difference := int(a&0xF0) - int(b&0xF0) + al
+	if difference < 0 {
+		difference -= 0x60
+	}
+	result.A = uint8(difference)
+
+	return result
+}
+
+func TestDecimalMode(t *testing.T) {
+	for _, variant := range []Variant{MOS6502, WDC65C02} {
+		for _, test := range []struct {
+			name      string
+			opcode    uint8
+			reference func(a, b uint8, carry bool, variant Variant) decimalResult
+		}{
+			{"ADC", 0x69, decimalADCReference},
+			{"SBC", 0xE9, decimalSBCReference},
+		} {
+			t.Run(variant.String()+"/"+test.name, func(t *testing.T) {
+				cpu, ram := newTestCPU(t, variant, test.opcode, 0x00)
+
+				for a := 0; a < 0x100; a++ {
+					for b := 0; b < 0x100; b++ {
+						for _, carry := range []bool{false, true} {
+							p := uint8(0x20 | flagD)
+							if carry {
+								p |= flagC
+							}
+							cpu.SetState(State{PC: 0x0200, SP: 0xFF, A: uint8(a), P: p})
+							ram[0x0201] = uint8(b)
+
+							step(t, cpu)
+
+							state := cpu.State()
+							got := decimalResult{
+								A: state.A,
+								C: state.P&flagC != 0,
+								Z: state.P&flagZ != 0,
+								N: state.P&flagN != 0,
+								V: state.P&flagV != 0,
+							}
+							want := test.reference(uint8(a), uint8(b), carry, variant)
+							if got != want {
+								t.Fatalf("%s #$%02X with A=$%02X C=%v: got %+v, want %+v", test.name, b, a, carry, got, want)
+							}
+						}
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package emulator
+
+// decodedOpCode is an entry of the CPU's decode table.
+type decodedOpCode struct {
+	OpCode
+	valid bool
+}
+
+// buildDecodeTable flattens the opcode maps of the current configuration into an array,
+// so decoding an instruction is an index instead of a map lookup.
+func (cpu *CPU) buildDecodeTable() {
+	for i := range cpu.decodeTable {
+		opCode, ok := cpu.lookup(uint8(i))
+		cpu.decodeTable[i] = decodedOpCode{opCode, ok}
+	}
+}
+
+func (cpu *CPU) decode(opcode uint8) (OpCode, bool) {
+	entry := &cpu.decodeTable[opcode]
+	return entry.OpCode, entry.valid
+}
+
+// cycles advances the cycle count. Without a clock channel it is a plain addition,
+// otherwise the CPU waits for a pulse for every cycle. In cycle accurate mode every cycle is a dummy read of the program counter.
+func (cpu *CPU) cycles(n int) {
+	if cpu.cycleAccurate {
+		for i := 0; i < n; i++ {
+			cpu.readBus(cpu.programCounter)
+		}
+		return
+	}
+
+	if cpu.clock == nil {
+		cpu.cycleCount += uint64(n)
+		return
+	}
+
+	for i := 0; i < n; i++ {
+		cpu.Pulse()
+	}
+}
+
+// spend makes sure n cycles have passed since start. In cycle accurate mode the bus accesses already counted theirs.
+func (cpu *CPU) spend(start uint64, n int) {
+	if cpu.cycleAccurate {
+		n -= int(cpu.cycleCount - start)
+	}
+	cpu.cycles(n)
+}
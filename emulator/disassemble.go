@@ -0,0 +1,116 @@
+package emulator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Disassembly is one instruction decoded from memory.
+type Disassembly struct {
+	Address  uint16
+	Bytes    []uint8 // The opcode and its operand
+	OpCode   OpCode
+	Valid    bool   // Whether the opcode is known to the current variant, if not it is shown as a .byte
+	Mnemonic string // The instruction, with the bit number for the Rockwell bit instructions
+	Operand  string // The operand in assembler syntax
+
+	Target    uint16 // The address the operand refers to, if HasTarget is set
+	HasTarget bool
+}
+
+func (d Disassembly) String() string {
+	if d.Operand == "" {
+		return d.Mnemonic
+	}
+
+	return d.Mnemonic + " " + d.Operand
+}
+
+// Disassemble decodes the instruction at address, using the instruction set of the current variant.
+// Memory is read with Bus.Peek, so I/O ports are not disturbed.
+func (cpu *CPU) Disassemble(address uint16) Disassembly {
+	opcode := cpu.bus.Peek(address)
+	opCode, ok := cpu.decode(opcode)
+	if !ok {
+		return Disassembly{
+			Address:  address,
+			Bytes:    []uint8{opcode},
+			Mnemonic: ".byte",
+			Operand:  fmt.Sprintf("$%02X", opcode),
+		}
+	}
+
+	d := Disassembly{
+		Address:  address,
+		Bytes:    []uint8{opcode},
+		OpCode:   opCode,
+		Valid:    true,
+		Mnemonic: opCode.Instruction.String(),
+	}
+	for i := uint16(1); i <= opCode.MemoryMode.Size(); i++ {
+		d.Bytes = append(d.Bytes, cpu.bus.Peek(address+i))
+	}
+
+	switch opCode.Instruction {
+	case BBR, BBS, RMB, SMB:
+		d.Mnemonic += fmt.Sprint(opcode >> 4 & 0x07)
+	}
+
+	var (
+		byteOperand uint8
+		wordOperand uint16
+	)
+	if len(d.Bytes) > 1 {
+		byteOperand = d.Bytes[1]
+		wordOperand = uint16(d.Bytes[1])
+	}
+	if len(d.Bytes) > 2 {
+		wordOperand |= uint16(d.Bytes[2]) << 8
+	}
+	next := address + 1 + opCode.MemoryMode.Size()
+
+	switch opCode.MemoryMode {
+	case Implicit:
+	case Accumulator:
+		d.Operand = "A"
+	case Immediate:
+		d.Operand = fmt.Sprintf("#$%02X", byteOperand)
+	case ZeroPage, ZeroPageX, ZeroPageY, IndirectX, IndirectY, ZeroPageIndirect:
+		d.Target, d.HasTarget = uint16(byteOperand), true
+		d.Operand = fmt.Sprintf("$%02X", byteOperand)
+	case Absolute, AbsoluteX, AbsoluteY, Indirect, AbsoluteIndexedIndirect:
+		d.Target, d.HasTarget = wordOperand, true
+		d.Operand = fmt.Sprintf("$%04X", wordOperand)
+	case Relative:
+		d.Target, d.HasTarget = next+uint16(int8(byteOperand)), true
+		d.Operand = fmt.Sprintf("$%04X", d.Target)
+	case ZeroPageRelative:
+		d.Target, d.HasTarget = next+uint16(int8(d.Bytes[2])), true
+		d.Operand = fmt.Sprintf("$%02X,$%04X", byteOperand, d.Target)
+	}
+
+	switch opCode.MemoryMode {
+	case ZeroPageX, AbsoluteX:
+		d.Operand += ",X"
+	case ZeroPageY, AbsoluteY:
+		d.Operand += ",Y"
+	case Indirect, ZeroPageIndirect:
+		d.Operand = "(" + d.Operand + ")"
+	case IndirectX, AbsoluteIndexedIndirect:
+		d.Operand = "(" + d.Operand + ",X)"
+	case IndirectY:
+		d.Operand = "(" + d.Operand + "),Y"
+	}
+
+	return d
+}
+
+// HexBytes formats the bytes of the instruction, like "BD 1A 81".
+func (d Disassembly) HexBytes() string {
+	hex := make([]string, len(d.Bytes))
+	for i, b := range d.Bytes {
+		hex[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return strings.Join(hex, " ")
+}
@@ -0,0 +1,55 @@
+package emulator
+
+import "testing"
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		variant Variant
+		program []uint8
+		want    string
+		target  uint16 // 0 if the operand doesn't refer to an address
+		hex     string
+	}{
+		{MOS6502, []uint8{0xEA}, "NOP", 0, "EA"},
+		{MOS6502, []uint8{0x0A}, "ASL A", 0, "0A"},
+		{MOS6502, []uint8{0xA9, 0x42}, "LDA #$42", 0, "A9 42"},
+		{MOS6502, []uint8{0xA5, 0x10}, "LDA $10", 0x0010, "A5 10"},
+		{MOS6502, []uint8{0xB5, 0x10}, "LDA $10,X", 0x0010, "B5 10"},
+		{MOS6502, []uint8{0xB6, 0x10}, "LDX $10,Y", 0x0010, "B6 10"},
+		{MOS6502, []uint8{0xD0, 0xFE}, "BNE $0200", 0x0200, "D0 FE"},
+		{MOS6502, []uint8{0xF0, 0x10}, "BEQ $0212", 0x0212, "F0 10"},
+		{MOS6502, []uint8{0xAD, 0x34, 0x12}, "LDA $1234", 0x1234, "AD 34 12"},
+		{MOS6502, []uint8{0xBD, 0x34, 0x12}, "LDA $1234,X", 0x1234, "BD 34 12"},
+		{MOS6502, []uint8{0xB9, 0x34, 0x12}, "LDA $1234,Y", 0x1234, "B9 34 12"},
+		{MOS6502, []uint8{0x6C, 0x34, 0x12}, "JMP ($1234)", 0x1234, "6C 34 12"},
+		{MOS6502, []uint8{0xA1, 0x10}, "LDA ($10,X)", 0x0010, "A1 10"},
+		{MOS6502, []uint8{0xB1, 0x10}, "LDA ($10),Y", 0x0010, "B1 10"},
+		{WDC65C02, []uint8{0xB2, 0x10}, "LDA ($10)", 0x0010, "B2 10"},
+		{WDC65C02, []uint8{0x7C, 0x34, 0x12}, "JMP ($1234,X)", 0x1234, "7C 34 12"},
+		{WDC65C02, []uint8{0x0F, 0x10, 0x02}, "BBR0 $10,$0205", 0x0205, "0F 10 02"},
+		{WDC65C02, []uint8{0xF7, 0x10}, "SMB7 $10", 0x0010, "F7 10"},
+	}
+
+	for _, test := range tests {
+		cpu, _ := newTestCPU(t, test.variant, test.program...)
+
+		got := cpu.Disassemble(0x0200)
+		if !got.Valid || got.String() != test.want || got.HexBytes() != test.hex {
+			t.Errorf("%v %s: got %q (%s), want %q", test.variant, test.hex, got, got.HexBytes(), test.want)
+		}
+		if got.HasTarget != (test.target != 0) || got.Target != test.target {
+			t.Errorf("%v %s: got target $%04X, want $%04X", test.variant, test.hex, got.Target, test.target)
+		}
+	}
+}
+
+func TestDisassembleUnknownOpcode(t *testing.T) {
+	// A JAM, with the undocumented opcodes turned off
+	cpu, _ := newTestCPU(t, MOS6502, 0x02, 0x10)
+	cpu.SetUndocumented(false)
+
+	got := cpu.Disassemble(0x0200)
+	if got.Valid || got.String() != ".byte $02" || len(got.Bytes) != 1 {
+		t.Errorf("got %q (%s), want a single .byte $02", got, got.HexBytes())
+	}
+}
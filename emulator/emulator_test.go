@@ -0,0 +1,54 @@
+package emulator
+
+import "testing"
+
+// testRAM is 64KB of RAM covering the whole address space.
+type testRAM [0x10000]uint8
+
+func (r *testRAM) Read(address uint16) uint8 {
+	return r[address]
+}
+
+func (r *testRAM) Write(address uint16, data uint8) {
+	r[address] = data
+}
+
+func (r *testRAM) Peek(address uint16) uint8 {
+	return r[address]
+}
+
+func (r *testRAM) Poke(address uint16, data uint8) {
+	r[address] = data
+}
+
+func (r *testRAM) Contains(address uint16) bool {
+	return true
+}
+
+// newTestCPU returns a CPU of the given variant with 64KB of RAM, and the program loaded at $0200.
+// The program counter points at the program and the stack pointer is $FF.
+func newTestCPU(t testing.TB, variant Variant, program ...uint8) (*CPU, *testRAM) {
+	t.Helper()
+
+	ram := &testRAM{}
+	copy(ram[0x0200:], program)
+
+	bus := &Bus{}
+	bus.AddMemory(ram)
+
+	cpu := NewCPU()
+	cpu.SetVariant(variant)
+	cpu.ConnectBus(bus)
+	cpu.SetState(State{PC: 0x0200, SP: 0xFF, P: 0x20})
+
+	return cpu, ram
+}
+
+// step executes one instruction and fails the test if it returns an error.
+func step(t testing.TB, cpu *CPU) {
+	t.Helper()
+
+	if err := cpu.Step(); err != nil {
+		t.Fatalf("step at $%04X: %v", cpu.State().PC, err)
+	}
+}
@@ -0,0 +1,30 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrHalted is returned by Step when the CPU has been halted by a JAM or STP opcode, only a reset gets it going again.
+var ErrHalted = errors.New("cpu halted")
+
+// UnknownOpcodePolicy decides what the CPU does with an opcode it can't decode.
+type UnknownOpcodePolicy uint8
+
+const (
+	// UnknownOpcodeNOP treats the opcode as a 1-byte NOP
+	UnknownOpcodeNOP UnknownOpcodePolicy = iota
+	// UnknownOpcodeHalt halts the CPU the way the NMOS JAM opcodes do, with the program counter left on the opcode
+	UnknownOpcodeHalt
+	// UnknownOpcodeFail stops before executing the opcode and returns an *UnknownOpcodeError from Step
+	UnknownOpcodeFail
+)
+
+type UnknownOpcodeError struct {
+	PC     uint16 // The address of the opcode
+	Opcode uint8
+}
+
+func (e *UnknownOpcodeError) Error() string {
+	return fmt.Sprintf("unknown opcode $%02x at $%04x", e.Opcode, e.PC)
+}
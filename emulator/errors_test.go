@@ -0,0 +1,63 @@
+package emulator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestUnknownOpcodePolicy(t *testing.T) {
+	tests := []struct {
+		policy UnknownOpcodePolicy
+		check  func(err error) bool
+		reason StopReason
+	}{
+		{UnknownOpcodeHalt, func(err error) bool { return errors.Is(err, ErrHalted) }, StopHalted},
+		{UnknownOpcodeFail, func(err error) bool {
+			var unknown *UnknownOpcodeError
+			return errors.As(err, &unknown) && unknown.PC == 0x0200 && unknown.Opcode == 0x03
+		}, StopError},
+	}
+
+	for _, test := range tests {
+		// $03 is SLO (zp,X) on the NMOS 6502, it is unknown with the undocumented opcodes off
+		cpu, ram := newTestCPU(t, MOS6502, 0x03, 0x10)
+		cpu.SetUndocumented(false)
+		cpu.SetUnknownOpcodePolicy(test.policy)
+
+		err := cpu.Step()
+		if !test.check(err) {
+			t.Errorf("policy %d: Step returned %v", test.policy, err)
+		}
+		if pc := cpu.State().PC; pc != 0x0200 {
+			t.Errorf("policy %d: pc is $%04X after Step, want it left on the opcode at $0200", test.policy, pc)
+		}
+		if err := cpu.Step(); !test.check(err) {
+			t.Errorf("policy %d: the second Step returned %v", test.policy, err)
+		}
+
+		cpu.Reset()
+		cpu.SetState(State{PC: 0x0200, SP: 0xFF, P: p(0)})
+		reason, err := cpu.Run(context.Background())
+		if reason != test.reason || !test.check(err) {
+			t.Errorf("policy %d: Run returned %v, %v", test.policy, reason, err)
+		}
+
+		// Start powers on through the reset vector
+		ram[0xFFFC], ram[0xFFFD] = 0x00, 0x02
+		if err := cpu.Start(); !test.check(err) {
+			t.Errorf("policy %d: Start returned %v", test.policy, err)
+		}
+		if pc := cpu.State().PC; pc != 0x0200 {
+			t.Errorf("policy %d: pc is $%04X after Start, want $0200", test.policy, pc)
+		}
+	}
+
+	// The default runs it as a 1-byte NOP
+	cpu, _ := newTestCPU(t, MOS6502, 0x03, 0x10)
+	cpu.SetUndocumented(false)
+	step(t, cpu)
+	if pc := cpu.State().PC; pc != 0x0201 {
+		t.Errorf("NOP policy: pc is $%04X, want $0201", pc)
+	}
+}
@@ -0,0 +1,192 @@
+package emulator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// External events, like input arriving or an interrupt being raised, happen at whatever time the outside world
+// decides. To make a run reproducible they are applied to the CPU between instructions and stamped with the cycle
+// count, so a log recorded with Record can be fed back at exactly the same cycles with Replay.
+
+type EventKind uint8
+
+const (
+	_ EventKind = iota
+	// EventInput means a byte arrived on the channel connected with ConnectInput
+	EventInput
+	// EventInterrupt means an IRQ was raised on the channel connected with ConnectInterrupt
+	EventInterrupt
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventInput:
+		return "input"
+	case EventInterrupt:
+		return "irq"
+	}
+
+	return fmt.Sprintf("EventKind(%d)", uint8(k))
+}
+
+// Event is an external event, Data is only used by EventInput.
+type Event struct {
+	Cycle uint64
+	Kind  EventKind
+	Data  uint8
+}
+
+// String formats the event the way it is written to a log, "<cycle> input <hex byte>" or "<cycle> irq".
+func (e Event) String() string {
+	if e.Kind == EventInput {
+		return fmt.Sprintf("%d %s %02x", e.Cycle, e.Kind, e.Data)
+	}
+
+	return fmt.Sprintf("%d %s", e.Cycle, e.Kind)
+}
+
+// InputPort is implemented by devices that can be fed input through ConnectInput.
+type InputPort interface {
+	// Input makes data available to the program
+	Input(data uint8)
+}
+
+// ConnectInput connects a channel whose bytes are handed to port while the CPU is running.
+func (cpu *CPU) ConnectInput(in <-chan uint8, port InputPort) {
+	cpu.inputChan = in
+	cpu.inputPort = port
+}
+
+// Record writes every external event to w as it is applied, one per line.
+func (cpu *CPU) Record(w io.Writer) {
+	cpu.recording = w
+}
+
+// Replay reads a log written by Record, and applies its events at the cycles they were recorded at instead of the
+// live ones. The channels connected with ConnectInput and ConnectInterrupt are ignored while replaying.
+// A line that isn't an event, or one stamped earlier than the line before it, is an error and nothing is replayed.
+func (cpu *CPU) Replay(r io.Reader) error {
+	var events []Event
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		event, ok := parseEvent(scanner.Text())
+		if !ok || (len(events) != 0 && event.Cycle < events[len(events)-1].Cycle) {
+			return fmt.Errorf("replay: line %d: invalid event %q", line, scanner.Text())
+		}
+
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	cpu.replaying = events
+	cpu.replay = true
+	return nil
+}
+
+// parseEvent parses a line written by Record.
+func parseEvent(line string) (Event, bool) {
+	var event Event
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return event, false
+	}
+
+	cycle, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return event, false
+	}
+	event.Cycle = cycle
+
+	switch {
+	case len(fields) == 2 && fields[1] == EventInterrupt.String():
+		event.Kind = EventInterrupt
+	case len(fields) == 3 && fields[1] == EventInput.String():
+		data, err := strconv.ParseUint(fields[2], 16, 8)
+		if err != nil {
+			return event, false
+		}
+		event.Kind = EventInput
+		event.Data = uint8(data)
+	default:
+		return event, false
+	}
+
+	return event, true
+}
+
+// eventQueue holds the live events that arrived since the last instruction.
+type eventQueue struct {
+	mu      sync.Mutex
+	events  []Event
+	pending uint32 // Set while events is not empty, so the CPU can check without locking
+}
+
+func (q *eventQueue) push(event Event) {
+	q.mu.Lock()
+	q.events = append(q.events, event)
+	atomic.StoreUint32(&q.pending, 1)
+	q.mu.Unlock()
+}
+
+func (q *eventQueue) take() []Event {
+	if atomic.LoadUint32(&q.pending) == 0 {
+		return nil
+	}
+
+	q.mu.Lock()
+	events := q.events
+	q.events = nil
+	atomic.StoreUint32(&q.pending, 0)
+	q.mu.Unlock()
+
+	return events
+}
+
+// applyEvents applies the external events that are due, it is called before every instruction.
+func (cpu *CPU) applyEvents() error {
+	if cpu.replay {
+		for len(cpu.replaying) != 0 && cpu.replaying[0].Cycle <= cpu.cycleCount {
+			cpu.applyEvent(cpu.replaying[0])
+			cpu.replaying = cpu.replaying[1:]
+		}
+		return nil
+	}
+
+	for _, event := range cpu.events.take() {
+		event.Cycle = cpu.cycleCount
+		cpu.applyEvent(event)
+
+		if cpu.recording != nil {
+			if _, err := fmt.Fprintln(cpu.recording, event); err != nil {
+				return fmt.Errorf("record: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (cpu *CPU) applyEvent(event Event) {
+	switch event.Kind {
+	case EventInput:
+		if cpu.inputPort != nil {
+			cpu.inputPort.Input(event.Data)
+		}
+	case EventInterrupt:
+		if cpu.interruptSource == nil {
+			cpu.interruptSource = cpu.irq.NewSource()
+		}
+		// Held until the CPU takes the IRQ
+		cpu.interruptSource.Assert()
+	}
+}
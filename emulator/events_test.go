@@ -0,0 +1,99 @@
+package emulator
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// newEventTestCPU runs a program that adds every byte of input to $10, and counts the IRQs in $11.
+func newEventTestCPU(t *testing.T) (*CPU, *testRAM) {
+	t.Helper()
+
+	cpu, ram := newTestCPU(t, MOS6502,
+		0xAD, 0x00, 0xF0, // $0200 LDA $F000
+		0x18,       //       $0203 CLC
+		0x65, 0x10, //       $0204 ADC $10
+		0x85, 0x10, //       $0206 STA $10
+		0x4C, 0x00, 0x02, // $0208 JMP $0200
+	)
+	ram[0xFFFE], ram[0xFFFF] = 0x00, 0x03
+	copy(ram[0x0300:], []uint8{
+		0xE6, 0x11, // $0300 INC $11
+		0x40, //       $0302 RTI
+	})
+
+	// Reads of $F000 are ORed with the RAM under it, which stays 0
+	io := NewIO(0xF000, nil, nil)
+	cpu.bus.AddMemory(io)
+	cpu.ConnectInput(nil, io.(InputPort))
+	cpu.ConnectInterrupt(nil)
+
+	return cpu, ram
+}
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+
+	cpu, ram := newEventTestCPU(t)
+	var log bytes.Buffer
+	cpu.Record(&log)
+
+	events := []Event{{Kind: EventInput, Data: 0x05}, {Kind: EventInterrupt}, {Kind: EventInput, Data: 0x07}}
+	for _, event := range events {
+		if _, err := cpu.RunFor(ctx, 100); err != nil {
+			t.Fatal(err)
+		}
+		cpu.events.push(event)
+	}
+	if _, err := cpu.RunFor(ctx, 200); err != nil {
+		t.Fatal(err)
+	}
+	if ram[0x10] != 0x0C || ram[0x11] != 1 {
+		t.Fatalf("got $%02X $%02X at $10 and $11, want the inputs added up and one IRQ", ram[0x10], ram[0x11])
+	}
+	if lines := strings.Count(log.String(), "\n"); lines != len(events) {
+		t.Fatalf("recorded %d events, want %d:\n%s", lines, len(events), log.String())
+	}
+
+	replayed, replayedRAM := newEventTestCPU(t)
+	if err := replayed.Replay(&log); err != nil {
+		t.Fatal(err)
+	}
+	// The recorded run stopped between instructions, so the replay stops at the same cycle
+	if _, err := replayed.RunFor(ctx, cpu.State().Cycles); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := replayed.State(), cpu.State(); got != want {
+		t.Errorf("replay ended in %+v, want %+v", got, want)
+	}
+	if *replayedRAM != *ram {
+		t.Error("the replayed memory differs from the recorded run's")
+	}
+}
+
+func TestReplayMalformed(t *testing.T) {
+	for _, log := range []string{
+		"",
+		"100",
+		"irq",
+		"100 irq 05",
+		"100 input",
+		"100 input 100",
+		"100 input zz",
+		"100 input 05 06",
+		"-1 irq",
+		"100 nmi",
+		"200 irq\n100 irq",
+	} {
+		cpu, _ := newTestCPU(t, MOS6502)
+		if err := cpu.Replay(strings.NewReader("10 irq\n" + log + "\n")); err == nil {
+			t.Errorf("replayed %q, want an error", log)
+		}
+		if cpu.replay {
+			t.Errorf("replaying after %q failed to parse", log)
+		}
+	}
+}
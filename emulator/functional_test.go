@@ -0,0 +1,208 @@
+package emulator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// FunctionalTest describes a self checking test program, in the style of
+// Klaus Dormann's 6502 test suite (https://github.com/Klaus2m5/6502_65C02_functional_tests).
+// The programs signal their result by jumping to themselves, the address of
+// that trap tells us whether the test passed or which check failed.
+type FunctionalTest struct {
+	Name    string
+	Variant Variant // The CPU the program runs on
+
+	Image []uint8 // The assembled binary
+	Load  uint16  // The address the binary is loaded at
+	Start uint16  // The address execution starts at
+
+	Success uint16 // The address of the trap that signals success, 0 if any trap is accepted
+	Error   uint16 // The address of a byte that is non-zero when the test failed, 0 if the test has none
+
+	// The address the program stores the number of the current test in, 0 if it does not track one.
+	TestCase uint16
+
+	// The address of the feedback register used by the interrupt test, 0 if the test does not use one.
+	// Bit 0 of the register drives the IRQ line, bit 1 drives the NMI line.
+	Feedback uint16
+
+	// The maximum number of instructions to run before giving up, 0 means no limit.
+	MaxInstructions uint64
+}
+
+type FunctionalResult struct {
+	Passed       bool
+	TrapAddress  uint16 // The address the program trapped at
+	TestCase     uint8  // The number of the last test that was started
+	Instructions uint64 // The number of instructions that were executed
+	Cycles       uint64 // The number of cycles that were executed
+}
+
+func (r FunctionalResult) String() string {
+	if r.Passed {
+		return fmt.Sprintf("passed after %d instructions (%d cycles)", r.Instructions, r.Cycles)
+	}
+
+	return fmt.Sprintf("failed test $%02x, trapped at $%04x after %d instructions", r.TestCase, r.TrapAddress, r.Instructions)
+}
+
+// feedbackPort is the I/O register the interrupt test uses to raise interrupts on itself.
+type feedbackPort struct {
+	Address uint16
+	Value   uint8
+
+	IRQ *InterruptSource
+	NMI *InterruptSource
+}
+
+func (f *feedbackPort) Read(address uint16) uint8 {
+	return f.Value
+}
+
+func (f *feedbackPort) Write(address uint16, data uint8) {
+	f.Value = data
+
+	if data&0x01 != 0 {
+		f.IRQ.Assert()
+	} else {
+		f.IRQ.Release()
+	}
+
+	if data&0x02 != 0 {
+		f.NMI.Assert()
+	} else {
+		f.NMI.Release()
+	}
+}
+
+func (f *feedbackPort) Contains(address uint16) bool {
+	return address == f.Address
+}
+
+// RunFunctionalTest loads the test into a fresh 64KB machine and steps the CPU until the program traps.
+func RunFunctionalTest(test FunctionalTest) (FunctionalResult, error) {
+	if int(test.Load)+len(test.Image) > 1<<16 {
+		return FunctionalResult{}, fmt.Errorf("%s: image of %d bytes does not fit at $%04x", test.Name, len(test.Image), test.Load)
+	}
+
+	bus := &Bus{}
+
+	cpu := NewCPU()
+	cpu.SetVariant(test.Variant)
+	cpu.ConnectBus(bus)
+	cpu.SetState(State{PC: test.Start, SP: 0xFF})
+
+	if test.Feedback != 0 {
+		bus.AddMemory(&feedbackPort{
+			Address: test.Feedback,
+			IRQ:     cpu.IRQ().NewSource(),
+			NMI:     cpu.NMI().NewSource(),
+		})
+	}
+
+	ram := &testRAM{}
+	copy(ram[test.Load:], test.Image)
+	bus.AddMemory(ram)
+
+	var result FunctionalResult
+	for test.MaxInstructions == 0 || result.Instructions < test.MaxInstructions {
+		pc := cpu.programCounter
+
+		if err := cpu.Step(); err != nil {
+			return result, fmt.Errorf("%s: %w", test.Name, err)
+		}
+		result.Instructions++
+
+		if cpu.programCounter == pc {
+			result.TrapAddress = pc
+			result.Passed = test.Success == 0 || pc == test.Success
+			if test.Error != 0 && bus.Read(test.Error) != 0 {
+				result.Passed = false
+			}
+			if test.TestCase != 0 {
+				result.TestCase = bus.Read(test.TestCase)
+			}
+			result.Cycles = cpu.cycleCount
+
+			return result, nil
+		}
+	}
+
+	return result, fmt.Errorf("%s: no trap after %d instructions, last pc $%04x", test.Name, result.Instructions, cpu.programCounter)
+}
+
+// loadTestImage reads a binary from testdata, a missing binary fails the test.
+func loadTestImage(t *testing.T, name string) []uint8 {
+	t.Helper()
+
+	image, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return image
+}
+
+func runFunctionalTest(t *testing.T, test FunctionalTest) {
+	t.Helper()
+
+	result, err := RunFunctionalTest(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed {
+		t.Fatalf("%s: %v", test.Name, result)
+	}
+	t.Logf("%s: %v", test.Name, result)
+}
+
+func TestFunctional(t *testing.T) {
+	runFunctionalTest(t, FunctionalTest{
+		Name:            "functional",
+		Image:           loadTestImage(t, "6502_functional_test.bin"),
+		Load:            0x0000,
+		Start:           0x0400,
+		Success:         0x0CAF,
+		TestCase:        0x0200,
+		MaxInstructions: 1_000_000,
+	})
+}
+
+func TestDecimal(t *testing.T) {
+	for _, test := range []struct {
+		variant Variant
+		image   string
+	}{
+		{MOS6502, "6502_decimal_test.bin"},
+		{WDC65C02, "65c02_decimal_test.bin"},
+	} {
+		t.Run(test.variant.String(), func(t *testing.T) {
+			runFunctionalTest(t, FunctionalTest{
+				Name:            "decimal",
+				Variant:         test.variant,
+				Image:           loadTestImage(t, test.image),
+				Load:            0x0200,
+				Start:           0x0200,
+				Success:         0x0203,
+				Error:           0x000B,
+				MaxInstructions: 100_000_000,
+			})
+		})
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	runFunctionalTest(t, FunctionalTest{
+		Name:            "interrupt",
+		Image:           loadTestImage(t, "6502_interrupt_test.bin"),
+		Load:            0x0400,
+		Start:           0x0400,
+		Success:         0x05DD,
+		TestCase:        0x0200,
+		Feedback:        0xBFFC,
+		MaxInstructions: 1_000_000,
+	})
+}
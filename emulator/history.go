@@ -0,0 +1,158 @@
+package emulator
+
+import "sync/atomic"
+
+// The history is a ring buffer with an undo record for each of the last Steps, holding the state before the step
+// and the old value of every address it wrote to. Stepping back restores them, external events such as input
+// and the lines driven by devices are not rewound, and neither is anything a device did on a read or write.
+// Only writes to memories that implement DebugMemory are recorded, the old value of the others can't be read
+// without risking a side effect, so they aren't undone and StepBackToWrite doesn't find them.
+
+type history struct {
+	records []undoRecord
+	head    int // The index the next record goes to
+	count   int // The number of valid records before head
+}
+
+type undoRecord struct {
+	state              State
+	previousCycleCount uint64
+
+	irqPending       bool
+	nmiPending       bool
+	nmiEdge          bool
+	interruptRequest bool
+	resetHeld        bool
+	halted           bool
+	waiting          bool
+
+	writes []undoWrite // In the order they were made
+}
+
+type undoWrite struct {
+	address uint16
+	data    uint8 // The value before the write
+}
+
+// SetHistory sets how many steps StepBack can undo, 0 turns the history off, which is the default.
+// Changing the depth clears the history.
+func (cpu *CPU) SetHistory(depth int) {
+	if depth <= 0 {
+		cpu.history = nil
+		return
+	}
+
+	cpu.history = &history{records: make([]undoRecord, depth)}
+}
+
+// History returns the number of steps that can currently be undone.
+func (cpu *CPU) History() int {
+	if cpu.history == nil {
+		return 0
+	}
+
+	return cpu.history.count
+}
+
+// StepBack undoes the last n steps, or as many as the history holds, and returns the number undone.
+func (cpu *CPU) StepBack(n int) int {
+	undone := 0
+	for ; undone < n && cpu.History() != 0; undone++ {
+		cpu.undo(cpu.history.pop())
+	}
+
+	return undone
+}
+
+// StepBackToWrite runs backwards until just before the last step that wrote to address, so the program counter
+// is at the instruction that made the write. If no step in the history wrote to address nothing is undone,
+// and it returns false.
+func (cpu *CPU) StepBackToWrite(address uint16) bool {
+	n := cpu.history.stepsSinceWrite(address)
+	if n == 0 {
+		return false
+	}
+
+	cpu.StepBack(n)
+	return true
+}
+
+func (h *history) clear() {
+	h.head = 0
+	h.count = 0
+}
+
+// begin starts the record for a step.
+func (h *history) begin(cpu *CPU) {
+	record := &h.records[h.head]
+	record.state = cpu.State()
+	record.previousCycleCount = cpu.previousCycleCount
+	record.irqPending = cpu.irqPending
+	record.nmiPending = cpu.nmiPending
+	record.nmiEdge = atomic.LoadUint32(&cpu.nmi.edge) != 0
+	record.interruptRequest = cpu.interruptSource != nil && cpu.interruptSource.Asserted()
+	record.resetHeld = cpu.resetHeld
+	record.halted = cpu.halted
+	record.waiting = cpu.waiting
+	record.writes = record.writes[:0]
+}
+
+func (h *history) write(address uint16, data uint8) {
+	record := &h.records[h.head]
+	record.writes = append(record.writes, undoWrite{address, data})
+}
+
+// commit finishes the record started by begin, overwriting the oldest one if the history is full.
+func (h *history) commit() {
+	h.head = (h.head + 1) % len(h.records)
+	if h.count < len(h.records) {
+		h.count++
+	}
+}
+
+func (h *history) pop() *undoRecord {
+	h.head = (h.head - 1 + len(h.records)) % len(h.records)
+	h.count--
+	return &h.records[h.head]
+}
+
+// stepsSinceWrite returns how many steps back the last write to address was made, counting the step that made it,
+// or 0 if it isn't in the history.
+func (h *history) stepsSinceWrite(address uint16) int {
+	if h == nil {
+		return 0
+	}
+
+	for n := 1; n <= h.count; n++ {
+		record := &h.records[(h.head-n+len(h.records))%len(h.records)]
+		for _, write := range record.writes {
+			if write.address == address {
+				return n
+			}
+		}
+	}
+
+	return 0
+}
+
+func (cpu *CPU) undo(record *undoRecord) {
+	for i := len(record.writes) - 1; i >= 0; i-- {
+		cpu.bus.Poke(record.writes[i].address, record.writes[i].data)
+	}
+
+	cpu.SetState(record.state)
+	cpu.previousCycleCount = record.previousCycleCount
+	cpu.irqPending = record.irqPending
+	cpu.nmiPending = record.nmiPending
+	atomic.StoreUint32(&cpu.nmi.edge, boolToUint32(record.nmiEdge))
+	if cpu.interruptSource != nil {
+		if record.interruptRequest {
+			cpu.interruptSource.Assert()
+		} else {
+			cpu.interruptSource.Release()
+		}
+	}
+	cpu.resetHeld = record.resetHeld
+	cpu.halted = record.halted
+	cpu.waiting = record.waiting
+}
@@ -0,0 +1,142 @@
+package emulator
+
+import "testing"
+
+func TestStepBack(t *testing.T) {
+	cpu, ram, _ := newInterruptTestCPU(t, p(0),
+		0xA9, 0x11, //       $0200 LDA #$11
+		0x85, 0x10, //       $0202 STA $10
+		0xE6, 0x10, //       $0204 INC $10
+		0x48,             // $0206 PHA
+		0x8D, 0x00, 0x10, // $0207 STA $1000
+	)
+	cpu.SetHistory(16)
+	nmi := cpu.NMI().NewSource()
+
+	type snapshot struct {
+		state      State
+		ram        testRAM
+		nmiPending bool
+		irqPending bool
+	}
+	take := func() snapshot {
+		return snapshot{cpu.State(), *ram, cpu.nmiPending, cpu.irqPending}
+	}
+
+	// LDA, STA, INC with an NMI arriving, the NMI, its RTI, PHA, STA
+	var snapshots []snapshot
+	for i := 0; i < 7; i++ {
+		if i == 2 {
+			nmi.Assert()
+		}
+		snapshots = append(snapshots, take())
+		step(t, cpu)
+	}
+	if !snapshots[3].nmiPending {
+		t.Fatal("the NMI isn't pending after the INC")
+	}
+
+	check := func(want snapshot) {
+		t.Helper()
+
+		got := take()
+		if got.state != want.state {
+			t.Errorf("got %+v, want %+v", got.state, want.state)
+		}
+		if got.nmiPending != want.nmiPending || got.irqPending != want.irqPending {
+			t.Errorf("got nmiPending=%v irqPending=%v, want %v %v", got.nmiPending, got.irqPending, want.nmiPending, want.irqPending)
+		}
+		if got.ram != want.ram {
+			t.Error("memory isn't restored")
+		}
+	}
+
+	if n := cpu.StepBack(3); n != 3 {
+		t.Fatalf("stepped back %d steps, want 3", n)
+	}
+	check(snapshots[4])
+
+	// Only what the history holds is undone
+	if n := cpu.StepBack(10); n != 4 {
+		t.Fatalf("stepped back %d steps, want 4", n)
+	}
+	check(snapshots[0])
+}
+
+func TestStepBackToWrite(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502,
+		0x85, 0x10, // $0200 STA $10
+		0xE8,       // $0202 INX
+		0x85, 0x20, // $0203 STA $20
+		0xE8, //       $0205 INX
+	)
+	cpu.SetHistory(16)
+	for i := 0; i < 4; i++ {
+		step(t, cpu)
+	}
+
+	if cpu.StepBackToWrite(0x30) {
+		t.Fatal("found a write to $30")
+	}
+	if n := cpu.History(); n != 4 {
+		t.Fatalf("%d steps in the history after a failed search, want 4", n)
+	}
+
+	if !cpu.StepBackToWrite(0x10) {
+		t.Fatal("didn't find the write to $10")
+	}
+	if state := cpu.State(); state.PC != 0x0200 || state.X != 0 {
+		t.Errorf("got pc $%04X X=%d, want the STA $10 at $0200", state.PC, state.X)
+	}
+}
+
+func TestHistoryRing(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502, 0xE8, 0xE8, 0xE8, 0xE8, 0xE8)
+	cpu.SetHistory(3)
+	for i := 0; i < 5; i++ {
+		step(t, cpu)
+	}
+
+	if n := cpu.History(); n != 3 {
+		t.Fatalf("%d steps in the history, want 3", n)
+	}
+	// The two oldest records were overwritten
+	if n := cpu.StepBack(5); n != 3 {
+		t.Fatalf("stepped back %d steps, want 3", n)
+	}
+	if state := cpu.State(); state.PC != 0x0202 || state.X != 2 {
+		t.Errorf("got pc $%04X X=%d, want $0202 X=2", state.PC, state.X)
+	}
+}
+
+// readCounter is a device without DebugMemory that counts its reads.
+type readCounter struct {
+	reads int
+}
+
+func (r *readCounter) Read(address uint16) uint8 {
+	r.reads++
+	return 0
+}
+
+func (r *readCounter) Write(address uint16, data uint8) {}
+
+func (r *readCounter) Contains(address uint16) bool {
+	return address == 0xF000
+}
+
+func TestHistorySkipsDebuglessMemory(t *testing.T) {
+	// STA $F000
+	cpu, _ := newTestCPU(t, MOS6502, 0x8D, 0x00, 0xF0)
+	device := &readCounter{}
+	cpu.bus.AddMemory(device)
+	cpu.SetHistory(16)
+
+	step(t, cpu)
+	if device.reads != 0 {
+		t.Errorf("the device was read %d times to record the write", device.reads)
+	}
+	if cpu.StepBackToWrite(0xF000) {
+		t.Error("recorded a write to a device without DebugMemory")
+	}
+}
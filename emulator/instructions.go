@@ -2,13 +2,39 @@ package emulator
 
 type iInstructionSet struct{}
 
-func (cpu *CPU) ProcessInstruction(opcode uint8) {
-	opCode := OpCodeMap[opcode]
+func (cpu *CPU) ProcessInstruction(opcode uint8) error {
+	opCode, ok := cpu.decode(opcode)
+
+	if !ok {
+		switch cpu.unknownOpcodePolicy {
+		case UnknownOpcodeHalt:
+			// Leave the program counter on the opcode, where the CPU stopped
+			cpu.programCounter--
+			cpu.halted = true
+			return ErrHalted
+		case UnknownOpcodeFail:
+			cpu.programCounter--
+			return &UnknownOpcodeError{PC: cpu.programCounter, Opcode: opcode}
+		}
+
+		opCode = OpCode{NOP, Implicit, 2}
+	}
 
 	// fmt.Printf("%04x - %v\n", cpu.programCounter-1, opCode)
 
 	cpu.programCounter += opCode.MemoryMode.Size()
 
+	cpu.pageCrossed = false
+	cpu.extraCycles = 0
+	cpu.delayedInterruptDisable = false
+	cpu.readModifyWrite = opCode.Instruction.ReadModifyWrite()
+	cpu.pageCrossPenalty = cpu.penalizesPageCross(opCode)
+
+	// Instructions without an operand still read the byte after the opcode
+	if (opCode.MemoryMode == Implicit || opCode.MemoryMode == Accumulator) && opCode.Cycles > 1 {
+		cpu.dummyRead(cpu.programCounter)
+	}
+
 	switch opCode.Instruction {
 	case ADC:
 		cpu.instructionSet.ADC(cpu, opCode.MemoryMode)
@@ -77,7 +103,7 @@ func (cpu *CPU) ProcessInstruction(opcode uint8) {
 	case LSR:
 		cpu.instructionSet.LSR(cpu, opCode.MemoryMode)
 	case NOP:
-		cpu.instructionSet.NOP(cpu)
+		cpu.instructionSet.NOP(cpu, opCode.MemoryMode)
 	case ORA:
 		cpu.instructionSet.ORA(cpu, opCode.MemoryMode)
 	case PHA:
@@ -122,14 +148,77 @@ func (cpu *CPU) ProcessInstruction(opcode uint8) {
 		cpu.instructionSet.TXS(cpu)
 	case TYA:
 		cpu.instructionSet.TYA(cpu)
+	case ALR:
+		cpu.instructionSet.ALR(cpu, opCode.MemoryMode)
+	case ANC:
+		cpu.instructionSet.ANC(cpu, opCode.MemoryMode)
+	case ARR:
+		cpu.instructionSet.ARR(cpu, opCode.MemoryMode)
+	case DCP:
+		cpu.instructionSet.DCP(cpu, opCode.MemoryMode)
+	case ISC:
+		cpu.instructionSet.ISC(cpu, opCode.MemoryMode)
+	case JAM:
+		cpu.instructionSet.JAM(cpu)
+	case LAS:
+		cpu.instructionSet.LAS(cpu, opCode.MemoryMode)
+	case LAX:
+		cpu.instructionSet.LAX(cpu, opCode.MemoryMode)
+	case RLA:
+		cpu.instructionSet.RLA(cpu, opCode.MemoryMode)
+	case RRA:
+		cpu.instructionSet.RRA(cpu, opCode.MemoryMode)
+	case SAX:
+		cpu.instructionSet.SAX(cpu, opCode.MemoryMode)
+	case SBX:
+		cpu.instructionSet.SBX(cpu, opCode.MemoryMode)
+	case SLO:
+		cpu.instructionSet.SLO(cpu, opCode.MemoryMode)
+	case SRE:
+		cpu.instructionSet.SRE(cpu, opCode.MemoryMode)
+	case BBR:
+		cpu.instructionSet.BBR(cpu, opCode.MemoryMode, opcode>>4&0x07)
+	case BBS:
+		cpu.instructionSet.BBS(cpu, opCode.MemoryMode, opcode>>4&0x07)
+	case RMB:
+		cpu.instructionSet.RMB(cpu, opCode.MemoryMode, opcode>>4&0x07)
+	case SMB:
+		cpu.instructionSet.SMB(cpu, opCode.MemoryMode, opcode>>4&0x07)
+	case BRA:
+		cpu.instructionSet.BRA(cpu)
+	case PHX:
+		cpu.instructionSet.PHX(cpu)
+	case PHY:
+		cpu.instructionSet.PHY(cpu)
+	case PLX:
+		cpu.instructionSet.PLX(cpu)
+	case PLY:
+		cpu.instructionSet.PLY(cpu)
+	case STZ:
+		cpu.instructionSet.STZ(cpu, opCode.MemoryMode)
+	case TRB:
+		cpu.instructionSet.TRB(cpu, opCode.MemoryMode)
+	case TSB:
+		cpu.instructionSet.TSB(cpu, opCode.MemoryMode)
+	case STP:
+		cpu.instructionSet.STP(cpu)
+	case WAI:
+		cpu.instructionSet.WAI(cpu)
 	}
 
-	// For emulation purposes, we need to delay the return by the number of cycles the instruction takes
-	for i := 0; i < opCode.Cycles; i++ {
-		cpu.Pulse()
+	cycles := opCode.Cycles + cpu.extraCycles
+	if cpu.pageCrossed && cpu.pageCrossPenalty {
+		cycles++
 	}
+
+	// For emulation purposes, we need to delay the return by the number of cycles the instruction takes
+	cpu.spend(cpu.instructionStart, cycles)
+
+	return nil
 }
 
+// MemoryMode resolves the operand of the current instruction. read is false for stores, which only need the address.
+// In cycle accurate mode it also makes the dummy accesses the 6502 makes while resolving the address.
 func (i iInstructionSet) MemoryMode(cpu *CPU, mode MemoryMode, read bool) (uint8, uint16, bool) {
 	var (
 		val         uint8
@@ -146,91 +235,198 @@ func (i iInstructionSet) MemoryMode(cpu *CPU, mode MemoryMode, read bool) (uint8
 		//	the next byte is the value to add
 		address = cpu.programCounter - mode.Size()
 		if read {
-			val = cpu.bus.Read(address)
+			val = cpu.fetch(address)
 		}
 	case ZeroPage: // Zero page
 		// the next byte is the lower bits of the address of the value to add
-		address = uint16(cpu.bus.Read(cpu.programCounter - mode.Size()))
+		address = uint16(cpu.fetch(cpu.programCounter - mode.Size()))
 		if read {
-			val = cpu.bus.Read(address)
+			val = i.readOperand(cpu, address)
 		}
 	case ZeroPageX: // Zero page, X
 		// the next byte is the lower bits of the address of the value to add, offset by X
-		address = uint16(cpu.bus.Read(cpu.programCounter-mode.Size()) + cpu.registers.X)
+		base := cpu.fetch(cpu.programCounter - mode.Size())
+		cpu.dummyRead(uint16(base))
+		address = uint16(base + cpu.registers.X)
 		if read {
-			val = cpu.bus.Read(address)
+			val = i.readOperand(cpu, address)
 		}
 	case ZeroPageY: // Zero page, Y
 		// the next byte is the lower bits of the address of the value to add, offset by X
-		address = uint16(cpu.bus.Read(cpu.programCounter-mode.Size()) + cpu.registers.Y)
+		base := cpu.fetch(cpu.programCounter - mode.Size())
+		cpu.dummyRead(uint16(base))
+		address = uint16(base + cpu.registers.Y)
 		if read {
-			val = cpu.bus.Read(address)
+			val = i.readOperand(cpu, address)
 		}
 	case Relative: // Relative
 		address = cpu.programCounter - mode.Size()
 		if read {
-			val = cpu.bus.Read(address) // this value is signed and will be added to the program counter
+			val = cpu.fetch(address) // this value is signed and will be added to the program counter
 		}
 	case Absolute: // Absolute
 		// the next two bytes are the address of the value to add
-		address = uint16(cpu.bus.Read(cpu.programCounter-mode.Size())) | uint16(cpu.bus.Read(cpu.programCounter-mode.Size()+1))<<8
+		address = uint16(cpu.fetch(cpu.programCounter-mode.Size())) | uint16(cpu.fetch(cpu.programCounter-mode.Size()+1))<<8
 		if read {
-			val = cpu.bus.Read(address)
+			val = i.readOperand(cpu, address)
 		}
 	case AbsoluteX: // Absolute, X
 		// the next two bytes are the address of the value to add, offset by X
-		address = (uint16(cpu.bus.Read(cpu.programCounter-mode.Size())) | uint16(cpu.bus.Read(cpu.programCounter-mode.Size()+1))<<8) + uint16(cpu.registers.X)
+		base := uint16(cpu.fetch(cpu.programCounter-mode.Size())) | uint16(cpu.fetch(cpu.programCounter-mode.Size()+1))<<8
+		address = base + uint16(cpu.registers.X)
+		cpu.pageCrossed = base&0xFF00 != address&0xFF00
+		i.indexedDummyRead(cpu, base, address, read)
 		if read {
-			val = cpu.bus.Read(address)
+			val = i.readOperand(cpu, address)
 		}
 	case AbsoluteY: // Absolute, Y
 		// the next two bytes are the address of the value to add, offset by Y
-		address = (uint16(cpu.bus.Read(cpu.programCounter-mode.Size())) | uint16(cpu.bus.Read(cpu.programCounter-mode.Size()+1))<<8) + uint16(cpu.registers.Y)
+		base := uint16(cpu.fetch(cpu.programCounter-mode.Size())) | uint16(cpu.fetch(cpu.programCounter-mode.Size()+1))<<8
+		address = base + uint16(cpu.registers.Y)
+		cpu.pageCrossed = base&0xFF00 != address&0xFF00
+		i.indexedDummyRead(cpu, base, address, read)
 		if read {
-			val = cpu.bus.Read(address)
+			val = i.readOperand(cpu, address)
 		}
 	case IndirectX: // Indirect, X
 		// the next byte is the lower bits of the address of the value to add, offset by X
-		tmp := uint16(cpu.bus.Read(cpu.programCounter-mode.Size())) + uint16(cpu.registers.X)
-		address = uint16(cpu.bus.Read(tmp)) | uint16(cpu.bus.Read(tmp+1))<<8
+		// the pointer and both of its bytes stay within the zero page
+		tmp := cpu.fetch(cpu.programCounter - mode.Size())
+		cpu.dummyRead(uint16(tmp))
+		tmp += cpu.registers.X
+		address = uint16(cpu.read(uint16(tmp))) | uint16(cpu.read(uint16(tmp+1)))<<8
 		if read {
-			val = cpu.bus.Read(address)
+			val = i.readOperand(cpu, address)
 		}
 	case IndirectY: // Indirect, Y
-		tmp := uint16(cpu.bus.Read(cpu.programCounter - mode.Size()))
-		address = (uint16(cpu.bus.Read(tmp)) | uint16(cpu.bus.Read(tmp+1))<<8) + uint16(cpu.registers.Y)
+		// the high byte of a pointer at $FF comes from $00
+		tmp := cpu.fetch(cpu.programCounter - mode.Size())
+		base := uint16(cpu.read(uint16(tmp))) | uint16(cpu.read(uint16(tmp+1)))<<8
+		address = base + uint16(cpu.registers.Y)
+		cpu.pageCrossed = base&0xFF00 != address&0xFF00
+		i.indexedDummyRead(cpu, base, address, read)
+		if read {
+			val = i.readOperand(cpu, address)
+		}
+	case Indirect: // Indirect
+		// the next two bytes are the address of the address to jump to
+		tmp := uint16(cpu.fetch(cpu.programCounter-mode.Size())) | uint16(cpu.fetch(cpu.programCounter-mode.Size()+1))<<8
+		high := tmp + 1
+		if cpu.variant == MOS6502 {
+			// The NMOS 6502 doesn't carry into the high byte of the vector address,
+			// so a vector at $xxFF takes its high byte from $xx00
+			high = tmp&0xFF00 | uint16(uint8(tmp)+1)
+		} else {
+			// The 65C02 spends the extra cycle it takes to fix this re-reading the operand
+			cpu.dummyRead(cpu.programCounter - 1)
+		}
+		address = uint16(cpu.read(tmp)) | uint16(cpu.read(high))<<8
+	case ZeroPageIndirect: // (Zero page)
+		// the next byte is the zero page address of the address of the value
+		tmp := cpu.fetch(cpu.programCounter - mode.Size())
+		address = uint16(cpu.read(uint16(tmp))) | uint16(cpu.read(uint16(tmp+1)))<<8
+		if read {
+			val = i.readOperand(cpu, address)
+		}
+	case AbsoluteIndexedIndirect: // (Absolute, X)
+		// the next two bytes offset by X are the address of the address to jump to
+		tmp := (uint16(cpu.fetch(cpu.programCounter-mode.Size())) | uint16(cpu.fetch(cpu.programCounter-mode.Size()+1))<<8) + uint16(cpu.registers.X)
+		cpu.dummyRead(cpu.programCounter - 1)
+		address = uint16(cpu.read(tmp)) | uint16(cpu.read(tmp+1))<<8
+	case ZeroPageRelative: // Zero page, relative
+		// the first byte is the zero page address of the value to test, the second is the branch offset
+		address = uint16(cpu.fetch(cpu.programCounter - mode.Size()))
 		if read {
-			val = cpu.bus.Read(address)
+			val = cpu.read(address)
+			cpu.dummyRead(address)
 		}
 	}
 
 	return val, address, accumulator
 }
 
+// readOperand reads the operand of the current instruction.
+// Read-modify-write instructions write the value straight back while they modify it, the 65C02 reads it again instead.
+func (i iInstructionSet) readOperand(cpu *CPU, address uint16) uint8 {
+	val := cpu.read(address)
+
+	if cpu.readModifyWrite {
+		if cpu.variant == WDC65C02 {
+			cpu.dummyRead(address)
+		} else {
+			cpu.dummyWrite(address, val)
+		}
+	}
+
+	return val
+}
+
+// indexedDummyRead makes the read the 6502 does while it adds the index to the low byte of the address.
+// On the NMOS 6502 it reads from the address before the carry into the high byte is fixed up, the 65C02 re-reads the
+// last operand byte instead. Reads only take the extra cycle when the index crosses a page, stores and
+// read-modify-write instructions always take it.
+func (i iInstructionSet) indexedDummyRead(cpu *CPU, base uint16, address uint16, read bool) {
+	if read && (!cpu.readModifyWrite || cpu.pageCrossPenalty) && !cpu.pageCrossed {
+		return
+	}
+
+	if cpu.variant == WDC65C02 {
+		cpu.dummyRead(cpu.programCounter - 1)
+		return
+	}
+
+	cpu.dummyRead(base&0xFF00 | address&0x00FF)
+}
+
 func (i iInstructionSet) ADC(cpu *CPU, mode MemoryMode) {
-	val, _, _ := i.MemoryMode(cpu, mode, true)
+	val, address, _ := i.MemoryMode(cpu, mode, true)
 
-	cpu.registers.A += val
+	i.add(cpu, val)
+
+	// The extra cycle the 65C02 takes to fix the flags in decimal mode reads the operand again
+	if cpu.flags.Decimal && cpu.variant == WDC65C02 {
+		cpu.dummyRead(address)
+	}
+}
+
+// add adds val and the carry to the accumulator, honoring the decimal flag.
+func (i iInstructionSet) add(cpu *CPU, val uint8) {
+	if cpu.flags.Decimal && cpu.variant == WDC65C02 {
+		i.cmosDecimalADC(cpu, val)
+		return
+	}
+
+	if cpu.flags.Decimal {
+		i.decimalADC(cpu, val)
+		return
+	}
+
+	i.binaryADC(cpu, val)
+}
+
+func (i iInstructionSet) binaryADC(cpu *CPU, val uint8) {
+	sum := uint16(cpu.registers.A) + uint16(val)
 	if cpu.flags.Carry {
-		cpu.registers.A++
+		sum++
 	}
+	result := uint8(sum)
 
-	cpu.flags.Carry = cpu.registers.A < val
-	cpu.flags.Zero = cpu.registers.A == 0
-	cpu.flags.Negative = cpu.registers.A&0x80 != 0
-	cpu.flags.Overflow = cpu.registers.A&0x80 != val&0x80
+	cpu.flags.Carry = sum > 0xFF
+	cpu.flags.Zero = result == 0
+	cpu.flags.Negative = result&0x80 != 0
+	// Overflow is set when both operands have the same sign and the result has a different sign
+	cpu.flags.Overflow = ^(cpu.registers.A^val)&(cpu.registers.A^result)&0x80 != 0
+
+	cpu.registers.A = result
 }
 
 func (i iInstructionSet) AND(cpu *CPU, mode MemoryMode) {
 	val, _, _ := i.MemoryMode(cpu, mode, true)
 
-	cpu.registers.A = cpu.registers.A & val
-	if cpu.registers.A == 0 {
-		cpu.flags.Zero = true
-	}
-	if cpu.registers.A > 0x7F {
-		cpu.flags.Negative = true
-	}
+	cpu.registers.A &= val
+
+	cpu.flags.Zero = cpu.registers.A == 0
+	cpu.flags.Negative = cpu.registers.A&0x80 != 0
 }
 
 func (i iInstructionSet) ASL(cpu *CPU, mode MemoryMode) {
@@ -241,112 +437,84 @@ func (i iInstructionSet) ASL(cpu *CPU, mode MemoryMode) {
 	if accumulator {
 		cpu.registers.A = val
 	} else {
-		cpu.bus.Write(addr, val)
+		cpu.write(addr, val)
 	}
 
 	cpu.flags.Zero = val == 0
 	cpu.flags.Negative = val&0x80 != 0
 }
 
-func (i iInstructionSet) BCC(cpu *CPU) {
-	if !cpu.flags.Carry {
-		val, _, _ := i.MemoryMode(cpu, Relative, true)
-		if val > 0x7F {
-			cpu.programCounter -= uint16(0xff-val) + 1
-		} else {
-			cpu.programCounter += uint16(val)
-		}
+// branch takes the branch if the condition holds.
+// A taken branch costs an extra cycle, and another one if the target is on a different page.
+func (i iInstructionSet) branch(cpu *CPU, condition bool) {
+	val, _, _ := i.MemoryMode(cpu, Relative, true)
+	if !condition {
+		return
 	}
+
+	target := cpu.programCounter + uint16(int8(val))
+
+	// The extra cycles read the next opcode, and the target before its high byte is fixed up
+	cpu.extraCycles++
+	cpu.dummyRead(cpu.programCounter)
+	if target&0xFF00 != cpu.programCounter&0xFF00 {
+		cpu.extraCycles++
+		cpu.dummyRead(cpu.programCounter&0xFF00 | target&0x00FF)
+	}
+
+	cpu.programCounter = target
+}
+
+func (i iInstructionSet) BCC(cpu *CPU) {
+	i.branch(cpu, !cpu.flags.Carry)
 }
 
 func (i iInstructionSet) BCS(cpu *CPU) {
-	if cpu.flags.Carry {
-		val, _, _ := i.MemoryMode(cpu, Relative, true)
-		if val > 0x7F {
-			cpu.programCounter -= uint16(0xff-val) + 1
-		} else {
-			cpu.programCounter += uint16(val)
-		}
-	}
+	i.branch(cpu, cpu.flags.Carry)
 }
 
 func (i iInstructionSet) BEQ(cpu *CPU) {
-	if cpu.flags.Zero {
-		val, _, _ := i.MemoryMode(cpu, Relative, true)
-		if val > 0x7F {
-			cpu.programCounter -= uint16(0xff-val) + 1
-		} else {
-			cpu.programCounter += uint16(val)
-		}
-	}
+	i.branch(cpu, cpu.flags.Zero)
 }
 
 func (i iInstructionSet) BIT(cpu *CPU, mode MemoryMode) {
 	val, _, _ := i.MemoryMode(cpu, mode, true)
 
 	cpu.flags.Zero = val&cpu.registers.A == 0
+
+	// The 65C02's BIT #imm only affects the zero flag
+	if mode == Immediate {
+		return
+	}
+
 	cpu.flags.Negative = val&0x80 == 0x80
 	cpu.flags.Overflow = val&0x40 == 0x40
 }
 
 func (i iInstructionSet) BMI(cpu *CPU) {
-	if cpu.flags.Negative {
-		val, _, _ := i.MemoryMode(cpu, Relative, true)
-		if val > 0x7F {
-			cpu.programCounter -= uint16(0xff-val) + 1
-		} else {
-			cpu.programCounter += uint16(val)
-		}
-	}
+	i.branch(cpu, cpu.flags.Negative)
 }
 
 func (i iInstructionSet) BNE(cpu *CPU) {
-	if !cpu.flags.Zero {
-		val, _, _ := i.MemoryMode(cpu, Relative, true)
-		if val > 0x7F {
-			cpu.programCounter -= uint16(0xff-val) + 1
-		} else {
-			cpu.programCounter += uint16(val)
-		}
-	}
+	i.branch(cpu, !cpu.flags.Zero)
 }
 
 func (i iInstructionSet) BPL(cpu *CPU) {
-	if !cpu.flags.Negative {
-		val, _, _ := i.MemoryMode(cpu, Relative, true)
-		if val > 0x7F {
-			cpu.programCounter -= uint16(0xff-val) + 1
-		} else {
-			cpu.programCounter += uint16(val)
-		}
-	}
+	i.branch(cpu, !cpu.flags.Negative)
 }
 
 func (i iInstructionSet) BRK(cpu *CPU) {
-	cpu.Interrupt()
-	cpu.flags.BreakCommand = true
+	// BRK is a 2 byte instruction, the byte after the opcode is skipped by the return address
+	cpu.programCounter++
+	cpu.interrupt(IRQVector, true)
 }
 
 func (i iInstructionSet) BVC(cpu *CPU) {
-	if !cpu.flags.Overflow {
-		val, _, _ := i.MemoryMode(cpu, Relative, true)
-		if val > 0x7F {
-			cpu.programCounter -= uint16(0xff-val) + 1
-		} else {
-			cpu.programCounter += uint16(val)
-		}
-	}
+	i.branch(cpu, !cpu.flags.Overflow)
 }
 
 func (i iInstructionSet) BVS(cpu *CPU) {
-	if cpu.flags.Overflow {
-		val, _, _ := i.MemoryMode(cpu, Relative, true)
-		if val > 0x7F {
-			cpu.programCounter -= uint16(0xff-val) + 1
-		} else {
-			cpu.programCounter += uint16(val)
-		}
-	}
+	i.branch(cpu, cpu.flags.Overflow)
 }
 
 func (i iInstructionSet) CLC(cpu *CPU) {
@@ -359,47 +527,48 @@ func (i iInstructionSet) CLD(cpu *CPU) {
 
 func (i iInstructionSet) CLI(cpu *CPU) {
 	cpu.flags.InterruptDisable = false
+	cpu.delayedInterruptDisable = true
 }
 
 func (i iInstructionSet) CLV(cpu *CPU) {
 	cpu.flags.Overflow = false
 }
 
+func (i iInstructionSet) compare(cpu *CPU, register uint8, val uint8) {
+	result := register - val
+
+	cpu.flags.Carry = register >= val
+	cpu.flags.Zero = register == val
+	cpu.flags.Negative = result&0x80 != 0
+}
+
 func (i iInstructionSet) CMP(cpu *CPU, mode MemoryMode) {
 	val, _, _ := i.MemoryMode(cpu, mode, true)
 
-	result := cpu.registers.A - val
-
-	cpu.flags.Carry = cpu.registers.A >= val
-	cpu.flags.Zero = cpu.registers.A == val
-	cpu.flags.Negative = result&0x80 != 0
+	i.compare(cpu, cpu.registers.A, val)
 }
 
 func (i iInstructionSet) CPX(cpu *CPU, mode MemoryMode) {
 	val, _, _ := i.MemoryMode(cpu, mode, true)
 
-	result := cpu.registers.X - val
-
-	cpu.flags.Carry = cpu.registers.X >= val
-	cpu.flags.Zero = cpu.registers.X == val
-	cpu.flags.Negative = result&0x80 != 0
+	i.compare(cpu, cpu.registers.X, val)
 }
 
 func (i iInstructionSet) CPY(cpu *CPU, mode MemoryMode) {
 	val, _, _ := i.MemoryMode(cpu, mode, true)
 
-	result := cpu.registers.Y - val
-
-	cpu.flags.Carry = cpu.registers.Y >= val
-	cpu.flags.Zero = cpu.registers.Y == val
-	cpu.flags.Negative = result&0x80 != 0
+	i.compare(cpu, cpu.registers.Y, val)
 }
 
 func (i iInstructionSet) DEC(cpu *CPU, mode MemoryMode) {
-	val, addr, _ := i.MemoryMode(cpu, mode, true)
+	val, addr, accumulator := i.MemoryMode(cpu, mode, true)
 
 	val--
-	cpu.bus.Write(addr, val)
+	if accumulator {
+		cpu.registers.A = val
+	} else {
+		cpu.write(addr, val)
+	}
 
 	cpu.flags.Zero = val == 0
 	cpu.flags.Negative = val&0x80 != 0
@@ -429,10 +598,14 @@ func (i iInstructionSet) EOR(cpu *CPU, mode MemoryMode) {
 }
 
 func (i iInstructionSet) INC(cpu *CPU, mode MemoryMode) {
-	val, addr, _ := i.MemoryMode(cpu, mode, true)
+	val, addr, accumulator := i.MemoryMode(cpu, mode, true)
 
 	val++
-	cpu.bus.Write(addr, val)
+	if accumulator {
+		cpu.registers.A = val
+	} else {
+		cpu.write(addr, val)
+	}
 
 	cpu.flags.Zero = val == 0
 	cpu.flags.Negative = val&0x80 != 0
@@ -458,9 +631,13 @@ func (i iInstructionSet) JMP(cpu *CPU, mode MemoryMode) {
 }
 
 func (i iInstructionSet) JSR(cpu *CPU) {
+	// The low byte of the target is read before the return address is pushed, the high byte after
+	low := cpu.fetch(cpu.programCounter - 2)
+	cpu.dummyRead(cpu.stackPointer.Address())
 	cpu.PushStack16(cpu.programCounter - 1)
-	_, address, _ := i.MemoryMode(cpu, Absolute, true)
-	cpu.programCounter = address
+	high := cpu.fetch(cpu.programCounter - 1)
+
+	cpu.programCounter = uint16(high)<<8 | uint16(low)
 }
 
 func (i iInstructionSet) LDA(cpu *CPU, mode MemoryMode) {
@@ -492,15 +669,18 @@ func (i iInstructionSet) LSR(cpu *CPU, mode MemoryMode) {
 	if accumulator {
 		cpu.registers.A = val
 	} else {
-		cpu.bus.Write(addr, val)
+		cpu.write(addr, val)
 	}
 
 	cpu.flags.Zero = val == 0
 	cpu.flags.Negative = val&0x80 != 0
 }
 
-func (i iInstructionSet) NOP(cpu *CPU) {
-	// Do nothing
+func (i iInstructionSet) NOP(cpu *CPU, mode MemoryMode) {
+	// Do nothing, the multi-byte NOPs still read their operand
+	if mode != Implicit {
+		i.MemoryMode(cpu, mode, true)
+	}
 }
 
 func (i iInstructionSet) ORA(cpu *CPU, mode MemoryMode) {
@@ -517,10 +697,11 @@ func (i iInstructionSet) PHA(cpu *CPU) {
 }
 
 func (i iInstructionSet) PHP(cpu *CPU) {
-	cpu.PushToStack(cpu.flags.ToByte())
+	cpu.PushToStack(cpu.flags.StackByte(true))
 }
 
 func (i iInstructionSet) PLA(cpu *CPU) {
+	cpu.dummyRead(cpu.stackPointer.Address())
 	cpu.registers.A = cpu.PopFromStack()
 
 	cpu.flags.Zero = cpu.registers.A == 0
@@ -528,7 +709,9 @@ func (i iInstructionSet) PLA(cpu *CPU) {
 }
 
 func (i iInstructionSet) PLP(cpu *CPU) {
-	cpu.flags.FromByte(cpu.PopFromStack())
+	cpu.dummyRead(cpu.stackPointer.Address())
+	cpu.flags.FromStackByte(cpu.PopFromStack())
+	cpu.delayedInterruptDisable = true
 }
 
 func (i iInstructionSet) ROL(cpu *CPU, mode MemoryMode) {
@@ -543,7 +726,7 @@ func (i iInstructionSet) ROL(cpu *CPU, mode MemoryMode) {
 	if accumulator {
 		cpu.registers.A = val
 	} else {
-		cpu.bus.Write(addr, val)
+		cpu.write(addr, val)
 	}
 
 	cpu.flags.Zero = val == 0
@@ -562,7 +745,7 @@ func (i iInstructionSet) ROR(cpu *CPU, mode MemoryMode) {
 	if accumulator {
 		cpu.registers.A = val
 	} else {
-		cpu.bus.Write(addr, val)
+		cpu.write(addr, val)
 	}
 
 	cpu.flags.Zero = val == 0
@@ -570,26 +753,43 @@ func (i iInstructionSet) ROR(cpu *CPU, mode MemoryMode) {
 }
 
 func (i iInstructionSet) RTI(cpu *CPU) {
-	cpu.flags.FromByte(cpu.PopFromStack())
+	cpu.dummyRead(cpu.stackPointer.Address())
+	cpu.flags.FromStackByte(cpu.PopFromStack())
 	cpu.programCounter = cpu.PopStack16()
 }
 
 func (i iInstructionSet) RTS(cpu *CPU) {
-	cpu.programCounter = cpu.PopStack16() + 1
+	cpu.dummyRead(cpu.stackPointer.Address())
+	cpu.programCounter = cpu.PopStack16()
+	cpu.dummyRead(cpu.programCounter)
+	cpu.programCounter++
 }
 
 func (i iInstructionSet) SBC(cpu *CPU, mode MemoryMode) {
-	val, _, _ := i.MemoryMode(cpu, mode, true)
+	val, address, _ := i.MemoryMode(cpu, mode, true)
 
-	cpu.registers.A -= val
-	if !cpu.flags.Carry {
-		cpu.registers.A--
+	i.subtract(cpu, val)
+
+	// The extra cycle the 65C02 takes to fix the flags in decimal mode reads the operand again
+	if cpu.flags.Decimal && cpu.variant == WDC65C02 {
+		cpu.dummyRead(address)
 	}
+}
 
-	cpu.flags.Carry = cpu.registers.A <= val
-	cpu.flags.Zero = cpu.registers.A == 0
-	cpu.flags.Negative = cpu.registers.A&0x80 != 0
-	cpu.flags.Overflow = cpu.registers.A&0x80 != val&0x80
+// subtract subtracts val and the borrow from the accumulator, honoring the decimal flag.
+func (i iInstructionSet) subtract(cpu *CPU, val uint8) {
+	if cpu.flags.Decimal && cpu.variant == WDC65C02 {
+		i.cmosDecimalSBC(cpu, val)
+		return
+	}
+
+	if cpu.flags.Decimal {
+		i.decimalSBC(cpu, val)
+		return
+	}
+
+	// A - M - (1 - C) is the same as A + ^M + C
+	i.binaryADC(cpu, ^val)
 }
 
 func (i iInstructionSet) SEC(cpu *CPU) {
@@ -602,21 +802,22 @@ func (i iInstructionSet) SED(cpu *CPU) {
 
 func (i iInstructionSet) SEI(cpu *CPU) {
 	cpu.flags.InterruptDisable = true
+	cpu.delayedInterruptDisable = true
 }
 
 func (i iInstructionSet) STA(cpu *CPU, mode MemoryMode) {
 	_, addr, _ := i.MemoryMode(cpu, mode, false)
-	cpu.bus.Write(addr, cpu.registers.A)
+	cpu.write(addr, cpu.registers.A)
 }
 
 func (i iInstructionSet) STX(cpu *CPU, mode MemoryMode) {
 	_, addr, _ := i.MemoryMode(cpu, mode, false)
-	cpu.bus.Write(addr, cpu.registers.X)
+	cpu.write(addr, cpu.registers.X)
 }
 
 func (i iInstructionSet) STY(cpu *CPU, mode MemoryMode) {
 	_, addr, _ := i.MemoryMode(cpu, mode, false)
-	cpu.bus.Write(addr, cpu.registers.Y)
+	cpu.write(addr, cpu.registers.Y)
 }
 
 func (i iInstructionSet) TAX(cpu *CPU) {
@@ -657,14 +858,3 @@ func (i iInstructionSet) TYA(cpu *CPU) {
 	cpu.flags.Zero = cpu.registers.A == 0
 	cpu.flags.Negative = cpu.registers.A&0x80 != 0
 }
-
-func (i *iInstructionSet) ReturnFromInterrupt(cpu *CPU) {
-	// Pop the flags from the stack
-	cpu.stackPointer.Pop()
-	cpu.flags.FromByte(cpu.bus.Read(cpu.stackPointer.Address()))
-	// Pop the program counter from the stack
-	cpu.stackPointer.Pop()
-	cpu.programCounter = uint16(cpu.bus.Read(cpu.stackPointer.Address()))
-	cpu.stackPointer.Pop()
-	cpu.programCounter |= uint16(cpu.bus.Read(cpu.stackPointer.Address())) << 8
-}
@@ -0,0 +1,212 @@
+package emulator
+
+import (
+	"errors"
+	"testing"
+)
+
+// p builds a status register value, bit 5 always reads as 1.
+func p(flags uint8) uint8 {
+	return flags | 0x20
+}
+
+func TestInstructionFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []uint8
+		before  State
+		memory  map[uint16]uint8
+		want    State // A, X, Y, SP and P are compared
+	}{
+		// ADC
+		{"ADC $7F+$01 overflows", []uint8{0x69, 0x01}, State{A: 0x7F}, nil, State{A: 0x80, P: p(flagN | flagV)}},
+		{"ADC $FF+$01 carries", []uint8{0x69, 0x01}, State{A: 0xFF}, nil, State{A: 0x00, P: p(flagZ | flagC)}},
+		{"ADC $80+$80 carries and overflows", []uint8{0x69, 0x80}, State{A: 0x80}, nil, State{A: 0x00, P: p(flagZ | flagC | flagV)}},
+		{"ADC adds the carry", []uint8{0x69, 0x01}, State{A: 0x01, P: p(flagC)}, nil, State{A: 0x03, P: p(0)}},
+		{"ADC $FF+$00 with carry", []uint8{0x69, 0x00}, State{A: 0xFF, P: p(flagC)}, nil, State{A: 0x00, P: p(flagZ | flagC)}},
+		{"ADC clears V", []uint8{0x69, 0x01}, State{A: 0x01, P: p(flagV | flagN)}, nil, State{A: 0x02, P: p(0)}},
+
+		// SBC
+		{"SBC $80-$01 overflows", []uint8{0xE9, 0x01}, State{A: 0x80, P: p(flagC)}, nil, State{A: 0x7F, P: p(flagC | flagV)}},
+		{"SBC with carry clear borrows", []uint8{0xE9, 0x03}, State{A: 0x05}, nil, State{A: 0x01, P: p(flagC)}},
+		{"SBC below zero clears C", []uint8{0xE9, 0x05}, State{A: 0x03, P: p(flagC)}, nil, State{A: 0xFE, P: p(flagN)}},
+		{"SBC to zero", []uint8{0xE9, 0x00}, State{A: 0x00, P: p(flagC)}, nil, State{A: 0x00, P: p(flagZ | flagC)}},
+		{"SBC $7F-$FF overflows", []uint8{0xE9, 0xFF}, State{A: 0x7F, P: p(flagC)}, nil, State{A: 0x80, P: p(flagN | flagV)}},
+		{"SBC $00-$00 with carry clear", []uint8{0xE9, 0x00}, State{A: 0x00}, nil, State{A: 0xFF, P: p(flagN)}},
+
+		// Compares
+		{"CMP equal", []uint8{0xC9, 0x42}, State{A: 0x42}, nil, State{A: 0x42, P: p(flagZ | flagC)}},
+		{"CMP lower", []uint8{0xC9, 0x20}, State{A: 0x10}, nil, State{A: 0x10, P: p(flagN)}},
+		{"CMP higher", []uint8{0xC9, 0x10}, State{A: 0x20}, nil, State{A: 0x20, P: p(flagC)}},
+		{"CMP unsigned", []uint8{0xC9, 0x01}, State{A: 0xFF}, nil, State{A: 0xFF, P: p(flagC | flagN)}},
+		{"CPX equal", []uint8{0xE0, 0x42}, State{X: 0x42}, nil, State{X: 0x42, P: p(flagZ | flagC)}},
+		{"CPX lower", []uint8{0xE0, 0x20}, State{X: 0x10}, nil, State{X: 0x10, P: p(flagN)}},
+		{"CPX higher", []uint8{0xE0, 0x10}, State{X: 0x20}, nil, State{X: 0x20, P: p(flagC)}},
+		{"CPY equal", []uint8{0xC0, 0x42}, State{Y: 0x42}, nil, State{Y: 0x42, P: p(flagZ | flagC)}},
+		{"CPY lower", []uint8{0xC0, 0x20}, State{Y: 0x10}, nil, State{Y: 0x10, P: p(flagN)}},
+		{"CPY higher", []uint8{0xC0, 0x10}, State{Y: 0x20}, nil, State{Y: 0x20, P: p(flagC)}},
+
+		// Logic
+		{"AND sets Z", []uint8{0x29, 0x0F}, State{A: 0xF0, P: p(flagN)}, nil, State{A: 0x00, P: p(flagZ)}},
+		{"AND sets N", []uint8{0x29, 0x80}, State{A: 0xF0, P: p(flagZ)}, nil, State{A: 0x80, P: p(flagN)}},
+		{"AND clears Z and N", []uint8{0x29, 0x0F}, State{A: 0x01, P: p(flagZ | flagN)}, nil, State{A: 0x01, P: p(0)}},
+		{"ORA sets Z", []uint8{0x09, 0x00}, State{A: 0x00}, nil, State{A: 0x00, P: p(flagZ)}},
+		{"ORA sets N", []uint8{0x09, 0x80}, State{A: 0x01}, nil, State{A: 0x81, P: p(flagN)}},
+		{"ORA clears Z and N", []uint8{0x09, 0x02}, State{A: 0x01, P: p(flagZ | flagN)}, nil, State{A: 0x03, P: p(0)}},
+		{"EOR sets Z", []uint8{0x49, 0xFF}, State{A: 0xFF}, nil, State{A: 0x00, P: p(flagZ)}},
+		{"EOR sets N", []uint8{0x49, 0xF0}, State{A: 0x0F}, nil, State{A: 0xFF, P: p(flagN)}},
+		{"EOR clears Z and N", []uint8{0x49, 0x01}, State{A: 0x02, P: p(flagZ | flagN)}, nil, State{A: 0x03, P: p(0)}},
+		{"BIT copies N and V", []uint8{0x24, 0x10}, State{A: 0x00}, map[uint16]uint8{0x10: 0xC0}, State{A: 0x00, P: p(flagZ | flagN | flagV)}},
+		{"BIT clears N, V and Z", []uint8{0x24, 0x10}, State{A: 0x01, P: p(flagZ | flagN | flagV)}, map[uint16]uint8{0x10: 0x3F}, State{A: 0x01, P: p(0)}},
+
+		// Shifts and rotates
+		{"ASL carries out", []uint8{0x0A}, State{A: 0x80}, nil, State{A: 0x00, P: p(flagZ | flagC)}},
+		{"ASL sets N", []uint8{0x0A}, State{A: 0x40, P: p(flagC)}, nil, State{A: 0x80, P: p(flagN)}},
+		{"LSR carries out", []uint8{0x4A}, State{A: 0x01, P: p(flagN)}, nil, State{A: 0x00, P: p(flagZ | flagC)}},
+		{"LSR clears N", []uint8{0x4A}, State{A: 0xF0, P: p(flagN | flagC)}, nil, State{A: 0x78, P: p(0)}},
+		{"ROL carries out", []uint8{0x2A}, State{A: 0x80}, nil, State{A: 0x00, P: p(flagZ | flagC)}},
+		{"ROL carries in", []uint8{0x2A}, State{A: 0x40, P: p(flagC)}, nil, State{A: 0x81, P: p(flagN)}},
+		{"ROR carries out", []uint8{0x6A}, State{A: 0x01}, nil, State{A: 0x00, P: p(flagZ | flagC)}},
+		{"ROR carries in", []uint8{0x6A}, State{A: 0x00, P: p(flagC)}, nil, State{A: 0x80, P: p(flagN)}},
+		{"ASL memory", []uint8{0x06, 0x10}, State{}, map[uint16]uint8{0x10: 0xC0}, State{P: p(flagN | flagC)}},
+		{"ROR memory", []uint8{0x66, 0x10}, State{P: p(flagC)}, map[uint16]uint8{0x10: 0x01}, State{P: p(flagN | flagC)}},
+
+		// Increments and decrements
+		{"INC wraps to zero", []uint8{0xE6, 0x10}, State{}, map[uint16]uint8{0x10: 0xFF}, State{P: p(flagZ)}},
+		{"INC sets N", []uint8{0xE6, 0x10}, State{}, map[uint16]uint8{0x10: 0x7F}, State{P: p(flagN)}},
+		{"DEC to zero", []uint8{0xC6, 0x10}, State{}, map[uint16]uint8{0x10: 0x01}, State{P: p(flagZ)}},
+		{"DEC wraps", []uint8{0xC6, 0x10}, State{}, map[uint16]uint8{0x10: 0x00}, State{P: p(flagN)}},
+		{"INX wraps to zero", []uint8{0xE8}, State{X: 0xFF}, nil, State{X: 0x00, P: p(flagZ)}},
+		{"INX sets N", []uint8{0xE8}, State{X: 0x7F}, nil, State{X: 0x80, P: p(flagN)}},
+		{"DEX wraps", []uint8{0xCA}, State{X: 0x00}, nil, State{X: 0xFF, P: p(flagN)}},
+		{"DEX to zero", []uint8{0xCA}, State{X: 0x01}, nil, State{X: 0x00, P: p(flagZ)}},
+		{"INY wraps to zero", []uint8{0xC8}, State{Y: 0xFF}, nil, State{Y: 0x00, P: p(flagZ)}},
+		{"DEY wraps", []uint8{0x88}, State{Y: 0x00}, nil, State{Y: 0xFF, P: p(flagN)}},
+
+		// Loads, transfers and the stack
+		{"LDA zero", []uint8{0xA9, 0x00}, State{A: 0x12, P: p(flagN)}, nil, State{A: 0x00, P: p(flagZ)}},
+		{"LDA negative", []uint8{0xA9, 0x80}, State{P: p(flagZ)}, nil, State{A: 0x80, P: p(flagN)}},
+		{"LDX zero", []uint8{0xA2, 0x00}, State{X: 0x12}, nil, State{X: 0x00, P: p(flagZ)}},
+		{"LDX negative", []uint8{0xA2, 0xFF}, State{}, nil, State{X: 0xFF, P: p(flagN)}},
+		{"LDY zero", []uint8{0xA0, 0x00}, State{Y: 0x12}, nil, State{Y: 0x00, P: p(flagZ)}},
+		{"LDY negative", []uint8{0xA0, 0x90}, State{}, nil, State{Y: 0x90, P: p(flagN)}},
+		{"STA leaves the flags", []uint8{0x85, 0x10}, State{A: 0x00, P: p(flagN)}, nil, State{A: 0x00, P: p(flagN)}},
+		{"TAX", []uint8{0xAA}, State{A: 0x80}, nil, State{A: 0x80, X: 0x80, P: p(flagN)}},
+		{"TAY", []uint8{0xA8}, State{A: 0x00, Y: 0x12}, nil, State{A: 0x00, Y: 0x00, P: p(flagZ)}},
+		{"TXA", []uint8{0x8A}, State{X: 0x00, A: 0x12}, nil, State{X: 0x00, A: 0x00, P: p(flagZ)}},
+		{"TYA", []uint8{0x98}, State{Y: 0xF0}, nil, State{Y: 0xF0, A: 0xF0, P: p(flagN)}},
+		{"TSX", []uint8{0xBA}, State{SP: 0x80}, nil, State{SP: 0x80, X: 0x80, P: p(flagN)}},
+		{"TXS leaves the flags", []uint8{0x9A}, State{X: 0x80, P: p(flagZ)}, nil, State{SP: 0x80, X: 0x80, P: p(flagZ)}},
+		{"PLA sets Z", []uint8{0x68}, State{SP: 0xFE, A: 0x12}, map[uint16]uint8{0x01FF: 0x00}, State{SP: 0xFF, A: 0x00, P: p(flagZ)}},
+		{"PLA sets N", []uint8{0x68}, State{SP: 0xFE}, map[uint16]uint8{0x01FF: 0x80}, State{SP: 0xFF, A: 0x80, P: p(flagN)}},
+		{"PLP ignores B", []uint8{0x28}, State{SP: 0xFE}, map[uint16]uint8{0x01FF: 0xFF}, State{SP: 0xFF, P: p(flagN | flagV | flagD | flagI | flagZ | flagC)}},
+
+		// Flag instructions
+		{"CLC", []uint8{0x18}, State{P: p(flagC)}, nil, State{P: p(0)}},
+		{"SEC", []uint8{0x38}, State{}, nil, State{P: p(flagC)}},
+		{"CLD", []uint8{0xD8}, State{P: p(flagD)}, nil, State{P: p(0)}},
+		{"SED", []uint8{0xF8}, State{}, nil, State{P: p(flagD)}},
+		{"CLI", []uint8{0x58}, State{P: p(flagI)}, nil, State{P: p(0)}},
+		{"SEI", []uint8{0x78}, State{}, nil, State{P: p(flagI)}},
+		{"CLV", []uint8{0xB8}, State{P: p(flagV)}, nil, State{P: p(0)}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, ram := newTestCPU(t, MOS6502, test.program...)
+			for address, data := range test.memory {
+				ram[address] = data
+			}
+
+			before := test.before
+			before.PC = 0x0200
+			if before.SP == 0 {
+				before.SP = 0xFF
+			}
+			if before.P == 0 {
+				before.P = p(0)
+			}
+			cpu.SetState(before)
+
+			step(t, cpu)
+
+			want := test.want
+			if want.SP == 0 {
+				want.SP = before.SP
+			}
+			got := cpu.State()
+			if got.A != want.A || got.X != want.X || got.Y != want.Y || got.SP != want.SP || got.P != want.P {
+				t.Errorf("got A=%02X X=%02X Y=%02X SP=%02X P=%08b, want A=%02X X=%02X Y=%02X SP=%02X P=%08b",
+					got.A, got.X, got.Y, got.SP, got.P, want.A, want.X, want.Y, want.SP, want.P)
+			}
+		})
+	}
+}
+
+func TestJAM(t *testing.T) {
+	for _, opcode := range []uint8{0x02, 0x12, 0x22, 0x32, 0x42, 0x52, 0x62, 0x72, 0x92, 0xB2, 0xD2, 0xF2} {
+		cpu, _ := newTestCPU(t, MOS6502, opcode)
+
+		step(t, cpu)
+		if err := cpu.Step(); !errors.Is(err, ErrHalted) {
+			t.Errorf("$%02X: got %v after the JAM, want ErrHalted", opcode, err)
+		}
+
+		// Without the undocumented opcodes it is left to the unknown opcode policy
+		cpu, _ = newTestCPU(t, MOS6502, opcode, 0xEA)
+		cpu.SetUndocumented(false)
+
+		step(t, cpu)
+		step(t, cpu)
+		if pc := cpu.State().PC; pc != 0x0202 {
+			t.Errorf("$%02X: got pc $%04X with undocumented opcodes off, want $0202", opcode, pc)
+		}
+	}
+}
+
+// accessCounter counts the bus accesses the CPU makes.
+type accessCounter struct {
+	NopObserver
+	accesses int
+}
+
+func (c *accessCounter) OnBusAccess(cpu *CPU, access BusAccess) {
+	c.accesses++
+}
+
+func TestShiftAbsoluteXCycles(t *testing.T) {
+	tests := []struct {
+		variant Variant
+		x       uint8
+		cycles  uint64
+	}{
+		{MOS6502, 0x01, 7},
+		{MOS6502, 0xFF, 7},
+		{WDC65C02, 0x01, 6},
+		{WDC65C02, 0xFF, 7},
+	}
+
+	for _, opcode := range []uint8{0x1E, 0x3E, 0x5E, 0x7E} {
+		for _, test := range tests {
+			for _, accurate := range []bool{false, true} {
+				// ASL $1080,X
+				cpu, _ := newTestCPU(t, test.variant, opcode, 0x80, 0x10)
+				cpu.SetCycleAccurate(accurate)
+				cpu.registers.X = test.x
+
+				counter := &accessCounter{}
+				cpu.AddObserver(counter)
+
+				start := cpu.cycleCount
+				step(t, cpu)
+
+				if got := cpu.cycleCount - start; got != test.cycles {
+					t.Errorf("$%02X on the %v with X=$%02X: took %d cycles, want %d", opcode, test.variant, test.x, got, test.cycles)
+				}
+				if accurate && uint64(counter.accesses) != test.cycles {
+					t.Errorf("$%02X on the %v with X=$%02X: made %d bus accesses, want %d", opcode, test.variant, test.x, counter.accesses, test.cycles)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package emulator
+
+import "sync/atomic"
+
+// The interrupt vectors
+const (
+	NMIVector   uint16 = 0xFFFA
+	ResetVector uint16 = 0xFFFC
+	IRQVector   uint16 = 0xFFFE
+)
+
+// InterruptLine is an active low, wired-OR input of the CPU.
+// Any number of sources can be attached, the line is asserted as long as at least one of them asserts it.
+// It is safe to assert and release sources from other goroutines.
+type InterruptLine struct {
+	count int32  // The number of sources currently asserting the line
+	edge  uint32 // Latched when the line goes from released to asserted
+}
+
+// InterruptSource is one device's connection to an InterruptLine.
+type InterruptSource struct {
+	line     *InterruptLine
+	asserted uint32
+}
+
+func (l *InterruptLine) NewSource() *InterruptSource {
+	return &InterruptSource{line: l}
+}
+
+// Asserted reports whether any source is holding the line.
+func (l *InterruptLine) Asserted() bool {
+	return atomic.LoadInt32(&l.count) > 0
+}
+
+// takeEdge reports whether the line has been asserted since the last call.
+func (l *InterruptLine) takeEdge() bool {
+	// The load keeps the common case, no edge, free of a locked instruction
+	return atomic.LoadUint32(&l.edge) != 0 && atomic.SwapUint32(&l.edge, 0) != 0
+}
+
+func (s *InterruptSource) Assert() {
+	if atomic.CompareAndSwapUint32(&s.asserted, 0, 1) {
+		if atomic.AddInt32(&s.line.count, 1) == 1 {
+			atomic.StoreUint32(&s.line.edge, 1)
+		}
+	}
+}
+
+func (s *InterruptSource) Release() {
+	if atomic.CompareAndSwapUint32(&s.asserted, 1, 0) {
+		atomic.AddInt32(&s.line.count, -1)
+	}
+}
+
+func (s *InterruptSource) Asserted() bool {
+	return atomic.LoadUint32(&s.asserted) != 0
+}
+
+// IRQ is the level sensitive interrupt request line, it is serviced through $FFFE while the I flag is clear.
+func (cpu *CPU) IRQ() *InterruptLine {
+	return &cpu.irq
+}
+
+// NMI is the edge triggered non-maskable interrupt line, it is serviced through $FFFA every time it becomes asserted.
+func (cpu *CPU) NMI() *InterruptLine {
+	return &cpu.nmi
+}
+
+// RESET holds the CPU in reset while it is asserted, the reset sequence runs once it is released.
+func (cpu *CPU) RESET() *InterruptLine {
+	return &cpu.reset
+}
+
+// pollInterrupts samples the interrupt lines, the 6502 does this at the end of every instruction.
+// interruptDisable is the I flag as the CPU saw it when polling, see delayedInterruptDisable.
+func (cpu *CPU) pollInterrupts(interruptDisable bool) {
+	if cpu.nmi.takeEdge() {
+		cpu.nmiPending = true
+	}
+	cpu.irqPending = cpu.irq.Asserted() && !interruptDisable
+
+	// A request from ConnectInterrupt is taken by the IRQ it is part of, one that arrives later waits for the next
+	if cpu.irqPending && cpu.interruptSource != nil {
+		cpu.interruptSource.Release()
+	}
+
+	// An interrupt always wakes up a WAI, even if it is masked
+	if cpu.nmiPending || cpu.irq.Asserted() {
+		cpu.waiting = false
+	}
+}
+
+// serviceInterrupt runs the interrupt sequence for a pending interrupt, it takes 7 cycles.
+// The first two read the next opcode, which is thrown away.
+func (cpu *CPU) serviceInterrupt(vector uint16) {
+	start := cpu.cycleCount
+	cpu.dummyRead(cpu.programCounter)
+	cpu.dummyRead(cpu.programCounter)
+	cpu.interrupt(vector, false)
+	cpu.spend(start, 7)
+}
@@ -0,0 +1,213 @@
+package emulator
+
+import "testing"
+
+// interruptCounter counts the interrupts the CPU services, by vector.
+type interruptCounter struct {
+	NopObserver
+	count map[uint16]int
+}
+
+func (c *interruptCounter) OnInterrupt(cpu *CPU, event InterruptEvent) {
+	if !event.BRK {
+		c.count[event.Vector]++
+	}
+}
+
+// newInterruptTestCPU loads the program at $0200 with the IRQ and NMI handlers at $0300 and $0310,
+// each of them is a bare RTI.
+func newInterruptTestCPU(t *testing.T, p uint8, program ...uint8) (*CPU, *testRAM, *interruptCounter) {
+	t.Helper()
+
+	cpu, ram := newTestCPU(t, MOS6502, program...)
+	cpu.SetState(State{PC: 0x0200, SP: 0xFF, P: p})
+	ram[0xFFFE], ram[0xFFFF] = 0x00, 0x03
+	ram[0xFFFA], ram[0xFFFB] = 0x10, 0x03
+	ram[0x0300] = 0x40 // RTI
+	ram[0x0310] = 0x40 // RTI
+
+	counter := &interruptCounter{count: map[uint16]int{}}
+	cpu.AddObserver(counter)
+
+	return cpu, ram, counter
+}
+
+func TestIRQLevelTriggered(t *testing.T) {
+	// NOP, NOP, NOP, CLI, NOP
+	cpu, _, counter := newInterruptTestCPU(t, p(flagI), 0xEA, 0xEA, 0xEA, 0x58, 0xEA)
+	irq := cpu.IRQ().NewSource()
+	irq.Assert()
+
+	// Masked by I
+	for i := 0; i < 3; i++ {
+		step(t, cpu)
+	}
+	if counter.count[IRQVector] != 0 {
+		t.Fatalf("serviced %d IRQs with I set", counter.count[IRQVector])
+	}
+
+	// CLI, the NOP after it, the IRQ and its RTI
+	for i := 0; i < 4; i++ {
+		step(t, cpu)
+	}
+	if counter.count[IRQVector] != 1 {
+		t.Fatalf("serviced %d IRQs after CLI, want 1", counter.count[IRQVector])
+	}
+
+	// The line is still held, so it is taken again right after the RTI
+	step(t, cpu)
+	if counter.count[IRQVector] != 2 {
+		t.Errorf("serviced %d IRQs while the line was held, want 2", counter.count[IRQVector])
+	}
+
+	// Released before the RTI, the program carries on
+	irq.Release()
+	step(t, cpu)
+	if pc := cpu.State().PC; pc != 0x0205 {
+		t.Errorf("got pc $%04X after the line was released, want $0205", pc)
+	}
+}
+
+func TestInterruptDisableDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       uint8
+		program []uint8
+		stack   uint8 // The value PLP pulls
+		steps   int   // The instructions that run before the IRQ is taken
+		pushedI bool  // Whether I is set in the P pushed by the IRQ
+	}{
+		{"CLI", p(flagI), []uint8{0x58, 0xEA, 0xEA}, 0, 2, false},
+		{"PLP", p(flagI), []uint8{0x28, 0xEA, 0xEA}, 0x00, 2, false},
+		// The IRQ was seen before SEI changed I, so it is taken after it with I set
+		{"SEI", p(0), []uint8{0x78, 0xEA, 0xEA}, 0, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, ram, counter := newInterruptTestCPU(t, test.p, test.program...)
+			cpu.SetState(State{PC: 0x0200, SP: 0xFE, P: test.p})
+			ram[0x01FF] = test.stack
+			cpu.IRQ().NewSource().Assert()
+
+			for i := 0; i < test.steps; i++ {
+				step(t, cpu)
+				if counter.count[IRQVector] != 0 {
+					t.Fatalf("IRQ taken after %d instructions, want %d", i+1, test.steps)
+				}
+			}
+
+			sp := cpu.State().SP
+			step(t, cpu)
+			if counter.count[IRQVector] != 1 {
+				t.Fatalf("IRQ not taken after %d instructions", test.steps)
+			}
+			if pushedI := ram[0x0100+uint16(sp)-2]&flagI != 0; pushedI != test.pushedI {
+				t.Errorf("pushed I is %v, want %v", pushedI, test.pushedI)
+			}
+		})
+	}
+}
+
+func TestNMIEdgeTriggered(t *testing.T) {
+	// NOPs with I set, which does not mask NMI
+	cpu, _, counter := newInterruptTestCPU(t, p(flagI), 0xEA, 0xEA, 0xEA, 0xEA, 0xEA, 0xEA)
+	nmi := cpu.NMI().NewSource()
+
+	nmi.Assert()
+	for i := 0; i < 5; i++ {
+		step(t, cpu)
+	}
+	if counter.count[NMIVector] != 1 {
+		t.Fatalf("serviced %d NMIs while the line was held, want 1", counter.count[NMIVector])
+	}
+
+	nmi.Release()
+	step(t, cpu)
+	nmi.Assert()
+	step(t, cpu)
+	step(t, cpu)
+	if counter.count[NMIVector] != 2 {
+		t.Errorf("serviced %d NMIs after a second edge, want 2", counter.count[NMIVector])
+	}
+}
+
+func TestSharedIRQ(t *testing.T) {
+	cpu, _, counter := newInterruptTestCPU(t, p(0), 0xEA, 0xEA, 0xEA, 0xEA, 0xEA, 0xEA)
+	a := cpu.IRQ().NewSource()
+	b := cpu.IRQ().NewSource()
+
+	a.Assert()
+	b.Assert()
+	a.Release()
+	if !cpu.IRQ().Asserted() {
+		t.Fatal("releasing one source released the line")
+	}
+
+	// NOP, the IRQ and its RTI
+	for i := 0; i < 3; i++ {
+		step(t, cpu)
+	}
+	if counter.count[IRQVector] != 1 {
+		t.Fatalf("serviced %d IRQs with one source asserting, want 1", counter.count[IRQVector])
+	}
+
+	// Servicing it doesn't acknowledge the devices, the line stays asserted until both let go
+	if !cpu.IRQ().Asserted() {
+		t.Error("servicing the IRQ released a device's source")
+	}
+	b.Release()
+	if cpu.IRQ().Asserted() {
+		t.Error("the line is asserted with both sources released")
+	}
+}
+
+func TestInterruptRequestSurvivesOtherIRQ(t *testing.T) {
+	cpu, _, counter := newInterruptTestCPU(t, p(0), 0xEA, 0xEA, 0xEA, 0xEA)
+	cpu.ConnectInterrupt(make(chan struct{}))
+	device := cpu.IRQ().NewSource()
+
+	// The device's IRQ is pending when the request arrives
+	device.Assert()
+	step(t, cpu)
+	device.Release()
+	cpu.events.push(Event{Kind: EventInterrupt})
+
+	// The device's IRQ, its RTI, then the request's IRQ
+	for i := 0; i < 3; i++ {
+		step(t, cpu)
+	}
+	if counter.count[IRQVector] != 2 {
+		t.Errorf("serviced %d IRQs, want the device's and the request's", counter.count[IRQVector])
+	}
+	if cpu.IRQ().Asserted() {
+		t.Error("the request is still asserted after it was serviced")
+	}
+}
+
+func TestSetOverflow(t *testing.T) {
+	// NOP, CLV, NOP, NOP, NOP
+	cpu, _ := newTestCPU(t, MOS6502, 0xEA, 0xB8, 0xEA, 0xEA, 0xEA)
+	so := cpu.SO().NewSource()
+
+	so.Assert()
+	step(t, cpu)
+	if cpu.State().P&flagV == 0 {
+		t.Fatal("V is clear after a falling edge on SO")
+	}
+
+	// Held low it is an edge only once, so V stays clear after CLV
+	step(t, cpu)
+	step(t, cpu)
+	if cpu.State().P&flagV != 0 {
+		t.Fatal("V was set again while SO was held")
+	}
+
+	so.Release()
+	step(t, cpu)
+	so.Assert()
+	step(t, cpu)
+	if cpu.State().P&flagV == 0 {
+		t.Error("V is clear after a second falling edge on SO")
+	}
+}
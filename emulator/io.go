@@ -3,6 +3,7 @@ package emulator
 import (
 	"bufio"
 	"fmt"
+	goio "io"
 	"os"
 )
 
@@ -11,6 +12,8 @@ type iIO struct {
 
 	In  <-chan uint8
 	Out chan<- uint8
+
+	buffered []uint8 // Input from Input, or taken off In by SaveState, read before In
 }
 
 func NewIO(address uint16, in <-chan uint8, out chan<- uint8) Memory {
@@ -26,6 +29,12 @@ func (io *iIO) Contains(address uint16) bool {
 }
 
 func (io *iIO) Read(address uint16) uint8 {
+	if len(io.buffered) != 0 {
+		data := io.buffered[0]
+		io.buffered = io.buffered[1:]
+		return data
+	}
+
 	if io.In == nil {
 		return 0
 	}
@@ -52,6 +61,50 @@ func (io *iIO) Write(address uint16, data uint8) {
 	// }
 }
 
+// Peek returns the next byte of input that has arrived without taking it, or 0.
+func (io *iIO) Peek(address uint16) uint8 {
+	if len(io.buffered) != 0 {
+		return io.buffered[0]
+	}
+
+	return 0
+}
+
+// Poke does nothing, the output can't be taken back.
+func (io *iIO) Poke(address uint16, data uint8) {}
+
+// Input queues data to be read, see ConnectInput.
+func (io *iIO) Input(data uint8) {
+	io.buffered = append(io.buffered, data)
+}
+
+// SaveState saves the input that has arrived but hasn't been read yet.
+func (io *iIO) SaveState(w goio.Writer) error {
+	for io.In != nil {
+		select {
+		case data := <-io.In:
+			io.buffered = append(io.buffered, data)
+			continue
+		default:
+		}
+		break
+	}
+
+	_, err := w.Write(io.buffered)
+	return err
+}
+
+// LoadState replaces the unread input with the saved input.
+func (io *iIO) LoadState(r goio.Reader) error {
+	data, err := goio.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	io.buffered = data
+	return nil
+}
+
 type ReadWrite byte
 
 const (
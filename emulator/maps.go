@@ -16,15 +16,20 @@ const (
 	Indirect
 	IndirectX
 	IndirectY
+
+	// 65C02 only
+	ZeroPageIndirect
+	AbsoluteIndexedIndirect
+	ZeroPageRelative
 )
 
 func (m MemoryMode) Size() uint16 {
 	switch m {
 	case Accumulator, Implicit:
 		return 0
-	case Immediate, ZeroPage, ZeroPageX, ZeroPageY, IndirectX, IndirectY:
+	case Immediate, ZeroPage, ZeroPageX, ZeroPageY, IndirectX, IndirectY, ZeroPageIndirect:
 		return 1
-	case Absolute, AbsoluteX, AbsoluteY, Indirect:
+	case Absolute, AbsoluteX, AbsoluteY, Indirect, AbsoluteIndexedIndirect, ZeroPageRelative:
 		return 2
 	case Relative:
 		return 1
@@ -61,6 +66,12 @@ func (m MemoryMode) String() string {
 		return "indirect, X"
 	case IndirectY:
 		return "indirect, Y"
+	case ZeroPageIndirect:
+		return "zero page indirect"
+	case AbsoluteIndexedIndirect:
+		return "absolute indexed indirect"
+	case ZeroPageRelative:
+		return "zero page, relative"
 	}
 
 	panic("unreachable")
@@ -126,6 +137,38 @@ const (
 	TXA
 	TXS
 	TYA
+
+	// Undocumented NMOS instructions
+	ALR
+	ANC
+	ARR
+	DCP
+	ISC
+	JAM
+	LAS
+	LAX
+	RLA
+	RRA
+	SAX
+	SBX
+	SLO
+	SRE
+
+	// 65C02 instructions
+	BBR
+	BBS
+	BRA
+	PHX
+	PHY
+	PLX
+	PLY
+	RMB
+	SMB
+	STP
+	STZ
+	TRB
+	TSB
+	WAI
 )
 
 func (i Instruction) String() string {
@@ -242,11 +285,88 @@ func (i Instruction) String() string {
 		return "TXS"
 	case TYA:
 		return "TYA"
+	case ALR:
+		return "ALR"
+	case ANC:
+		return "ANC"
+	case ARR:
+		return "ARR"
+	case DCP:
+		return "DCP"
+	case ISC:
+		return "ISC"
+	case JAM:
+		return "JAM"
+	case LAS:
+		return "LAS"
+	case LAX:
+		return "LAX"
+	case RLA:
+		return "RLA"
+	case RRA:
+		return "RRA"
+	case SAX:
+		return "SAX"
+	case SBX:
+		return "SBX"
+	case SLO:
+		return "SLO"
+	case SRE:
+		return "SRE"
+	case BBR:
+		return "BBR"
+	case BBS:
+		return "BBS"
+	case BRA:
+		return "BRA"
+	case PHX:
+		return "PHX"
+	case PHY:
+		return "PHY"
+	case PLX:
+		return "PLX"
+	case PLY:
+		return "PLY"
+	case RMB:
+		return "RMB"
+	case SMB:
+		return "SMB"
+	case STP:
+		return "STP"
+	case STZ:
+		return "STZ"
+	case TRB:
+		return "TRB"
+	case TSB:
+		return "TSB"
+	case WAI:
+		return "WAI"
 	}
 
 	panic("unreachable")
 }
 
+// PageCrossPenalty reports whether the instruction takes an extra cycle when an indexed read crosses a page boundary.
+// Stores and read-modify-write instructions always take the extra cycle, it is already part of their base cycle count.
+func (i Instruction) PageCrossPenalty() bool {
+	switch i {
+	case ADC, AND, BIT, CMP, EOR, LDA, LDX, LDY, NOP, ORA, SBC, LAS, LAX:
+		return true
+	}
+
+	return false
+}
+
+// ReadModifyWrite reports whether the instruction reads its operand from memory, modifies it and writes it back.
+func (i Instruction) ReadModifyWrite() bool {
+	switch i {
+	case ASL, DEC, INC, LSR, ROL, ROR, DCP, ISC, RLA, RRA, SLO, SRE, RMB, SMB, TRB, TSB:
+		return true
+	}
+
+	return false
+}
+
 type OpCode struct {
 	Instruction Instruction
 	MemoryMode  MemoryMode
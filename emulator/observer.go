@@ -0,0 +1,121 @@
+package emulator
+
+import "fmt"
+
+// Observer gets notified of what the CPU is doing, for tracers, profilers and debuggers.
+// Embed NopObserver to only implement the events you care about.
+type Observer interface {
+	// OnInstruction is called after every instruction the CPU executes
+	OnInstruction(cpu *CPU, event InstructionEvent)
+	// OnInterrupt is called when the CPU enters an interrupt handler, including for BRK
+	OnInterrupt(cpu *CPU, event InterruptEvent)
+	// OnBusAccess is called for every read and write the CPU makes
+	OnBusAccess(cpu *CPU, access BusAccess)
+}
+
+type InstructionEvent struct {
+	PC     uint16 // The address of the opcode
+	Opcode uint8
+	OpCode OpCode
+	Cycles uint64 // The number of cycles the instruction took
+}
+
+type InterruptEvent struct {
+	Vector  uint16 // The vector the handler was read from
+	BRK     bool   // Set when the interrupt was caused by a BRK instruction
+	Return  uint16 // The return address that was pushed
+	Cycle   uint64 // The cycle count when the interrupt was entered
+	Elapsed uint64 // The number of cycles since the previous interrupt
+}
+
+// BusAccess is one read or write the CPU made.
+type BusAccess struct {
+	Address uint16
+	Data    uint8
+	Write   bool
+}
+
+func (b BusAccess) String() string {
+	if b.Write {
+		return fmt.Sprintf("W %04x %02x", b.Address, b.Data)
+	}
+
+	return fmt.Sprintf("R %04x %02x", b.Address, b.Data)
+}
+
+type NopObserver struct{}
+
+func (NopObserver) OnInstruction(cpu *CPU, event InstructionEvent) {}
+func (NopObserver) OnInterrupt(cpu *CPU, event InterruptEvent)     {}
+func (NopObserver) OnBusAccess(cpu *CPU, access BusAccess)         {}
+
+func (cpu *CPU) AddObserver(observer Observer) {
+	cpu.observers = append(cpu.observers, observer)
+}
+
+func (cpu *CPU) RemoveObserver(observer Observer) {
+	for i, o := range cpu.observers {
+		if o == observer {
+			cpu.observers = append(cpu.observers[:i], cpu.observers[i+1:]...)
+			return
+		}
+	}
+}
+
+// read reads data from the bus on behalf of the CPU.
+func (cpu *CPU) read(address uint16) uint8 {
+	data := cpu.readBus(address)
+	if cpu.watchpoints != nil {
+		cpu.watch(address, WatchRead)
+	}
+
+	return data
+}
+
+// fetch reads an opcode or operand byte. Unlike read it doesn't trigger watchpoints, the program isn't data.
+func (cpu *CPU) fetch(address uint16) uint8 {
+	return cpu.readBus(address)
+}
+
+// readBus makes a read cycle, without checking watchpoints.
+func (cpu *CPU) readBus(address uint16) uint8 {
+	if cpu.cycleAccurate {
+		cpu.Pulse()
+		cpu.stall()
+	}
+	data := cpu.bus.Read(address)
+
+	for _, observer := range cpu.observers {
+		observer.OnBusAccess(cpu, BusAccess{Address: address, Data: data})
+	}
+
+	return data
+}
+
+// write writes data to the bus on behalf of the CPU.
+func (cpu *CPU) write(address uint16, data uint8) {
+	cpu.writeBus(address, data)
+	if cpu.watchpoints != nil {
+		cpu.watch(address, WatchWrite)
+	}
+}
+
+// writeBus makes a write cycle, without checking watchpoints.
+func (cpu *CPU) writeBus(address uint16, data uint8) {
+	if cpu.history != nil {
+		if old, ok := cpu.bus.peekDebug(address); ok {
+			cpu.history.write(address, old)
+		}
+	}
+	if cpu.cycleAccurate {
+		cpu.Pulse()
+		if cpu.variant == WDC65C02 {
+			cpu.stall()
+		}
+	}
+	cpu.bus.Write(address, data)
+
+	for _, observer := range cpu.observers {
+		observer.OnBusAccess(cpu, BusAccess{Address: address, Data: data, Write: true})
+	}
+}
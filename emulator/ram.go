@@ -1,5 +1,10 @@
 package emulator
 
+import (
+	"fmt"
+	"io"
+)
+
 type iRAM struct {
 	Offset uint16
 	Data   []uint8
@@ -23,3 +28,29 @@ func (ram *iRAM) Write(address uint16, data uint8) {
 func (ram *iRAM) Contains(address uint16) bool {
 	return address >= ram.Offset && address < ram.Offset+uint16(len(ram.Data))
 }
+
+func (ram *iRAM) Peek(address uint16) uint8 {
+	return ram.Read(address)
+}
+
+func (ram *iRAM) Poke(address uint16, data uint8) {
+	ram.Write(address, data)
+}
+
+func (ram *iRAM) SaveState(w io.Writer) error {
+	_, err := w.Write(ram.Data)
+	return err
+}
+
+func (ram *iRAM) LoadState(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) != len(ram.Data) {
+		return fmt.Errorf("%w: RAM is %d bytes, the state has %d", ErrInvalidSaveState, len(ram.Data), len(data))
+	}
+
+	copy(ram.Data, data)
+	return nil
+}
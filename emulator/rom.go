@@ -23,3 +23,11 @@ func (rom *ROM) Write(address uint16, data uint8) {
 func (rom *ROM) Contains(address uint16) bool {
 	return address >= rom.Offset && (address-rom.Offset) < uint16(len(rom.Data))
 }
+
+func (rom *ROM) Peek(address uint16) uint8 {
+	return rom.Read(address)
+}
+
+func (rom *ROM) Poke(address uint16, data uint8) {
+	// do nothing
+}
@@ -0,0 +1,263 @@
+package emulator
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// StopReason tells why one of the Run methods returned.
+type StopReason uint8
+
+const (
+	_ StopReason = iota
+	// StopCancelled means the context was cancelled
+	StopCancelled
+	// StopCycleLimit means the cycle budget was used up
+	StopCycleLimit
+	// StopInstructionLimit means the requested number of instructions were executed
+	StopInstructionLimit
+	// StopBreakpoint means the program counter reached the requested address
+	StopBreakpoint
+	// StopWatchpoint means an instruction accessed a watched address, see AddWatchpoint
+	StopWatchpoint
+	// StopHalted means the CPU was halted by a JAM or STP
+	StopHalted
+	// StopError means Step returned an error
+	StopError
+)
+
+func (r StopReason) String() string {
+	switch r {
+	case StopCancelled:
+		return "cancelled"
+	case StopCycleLimit:
+		return "cycle limit"
+	case StopInstructionLimit:
+		return "instruction limit"
+	case StopBreakpoint:
+		return "breakpoint"
+	case StopWatchpoint:
+		return "watchpoint"
+	case StopHalted:
+		return "halted"
+	case StopError:
+		return "error"
+	}
+
+	panic("unreachable")
+}
+
+type runLimits struct {
+	cycles          uint64 // Stop once the cycle count reaches this, if hasCycles is set
+	hasCycles       bool
+	instructions    uint64 // Stop after this many instructions, if hasInstructions is set
+	hasInstructions bool
+	breakpoint      uint16 // Stop when the program counter reaches this, if hasBreakpoint is set
+	hasBreakpoint   bool
+	until           func(cpu *CPU) bool // Stop when this returns true after an instruction, if it is set
+}
+
+// Run runs the CPU until the context is cancelled or the CPU stops.
+// The returned error is the context's error, ErrHalted, or the error returned by Step.
+func (cpu *CPU) Run(ctx context.Context) (StopReason, error) {
+	return cpu.run(ctx, runLimits{})
+}
+
+// RunFor runs the CPU for at least the given number of cycles, it only stops at instruction boundaries.
+func (cpu *CPU) RunFor(ctx context.Context, cycles uint64) (StopReason, error) {
+	return cpu.run(ctx, runLimits{cycles: cpu.cycleCount + cycles, hasCycles: true})
+}
+
+// RunUntil runs the CPU until the program counter reaches pc.
+// At least one instruction is executed, so RunUntil can be used to continue from a breakpoint.
+func (cpu *CPU) RunUntil(ctx context.Context, pc uint16) (StopReason, error) {
+	return cpu.run(ctx, runLimits{breakpoint: pc, hasBreakpoint: true})
+}
+
+// RunUntilFunc runs the CPU until stop returns true, it is called after every instruction
+// and stops the run with StopBreakpoint. At least one instruction is executed, like RunUntil.
+func (cpu *CPU) RunUntilFunc(ctx context.Context, stop func(cpu *CPU) bool) (StopReason, error) {
+	return cpu.run(ctx, runLimits{until: stop})
+}
+
+// RunInstructions runs the CPU for n instructions, servicing an interrupt counts as an instruction.
+func (cpu *CPU) RunInstructions(ctx context.Context, n uint64) (StopReason, error) {
+	return cpu.run(ctx, runLimits{instructions: n, hasInstructions: true})
+}
+
+func (cpu *CPU) run(ctx context.Context, limits runLimits) (StopReason, error) {
+	// Watching the context and the input and interrupt channels happens on another goroutine,
+	// so the loop below only has to check a flag instead of doing a channel operation per instruction.
+	var cancelled uint32
+	done := make(chan struct{})
+	defer close(done)
+
+	// The events are queued and applied between instructions, see applyEvents
+	input, interrupt := cpu.inputChan, cpu.interruptChan
+	if cpu.replay {
+		input, interrupt = nil, nil
+	}
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				atomic.StoreUint32(&cancelled, 1)
+				return
+			case data := <-input:
+				cpu.events.push(Event{Kind: EventInput, Data: data})
+			case <-interrupt:
+				cpu.events.push(Event{Kind: EventInterrupt})
+			}
+		}
+	}()
+
+	// A watchpoint hit by a Step outside the Run methods is old news
+	cpu.watchHit = false
+
+	var instructions uint64
+
+	for {
+		if limits.hasCycles && cpu.cycleCount >= limits.cycles {
+			return StopCycleLimit, nil
+		}
+
+		if limits.hasInstructions && instructions >= limits.instructions {
+			return StopInstructionLimit, nil
+		}
+
+		if atomic.LoadUint32(&cancelled) != 0 {
+			return StopCancelled, ctx.Err()
+		}
+
+		if cpu.pacer != nil {
+			if err := cpu.pacer.Sync(ctx, cpu.cycleCount); err != nil {
+				return StopCancelled, err
+			}
+		}
+
+		if err := cpu.Step(); err != nil {
+			if errors.Is(err, ErrHalted) {
+				return StopHalted, err
+			}
+
+			return StopError, err
+		}
+		if cpu.stalled {
+			continue
+		}
+		instructions++
+
+		if limits.hasBreakpoint && cpu.programCounter == limits.breakpoint {
+			return StopBreakpoint, nil
+		}
+
+		if limits.until != nil && limits.until(cpu) {
+			return StopBreakpoint, nil
+		}
+
+		if cpu.watchHit {
+			cpu.watchHit = false
+			return StopWatchpoint, nil
+		}
+
+		if cpu.breakpoints != nil && cpu.breakpoints[cpu.programCounter] {
+			return StopBreakpoint, nil
+		}
+	}
+}
+
+// AddBreakpoint makes the Run methods stop with StopBreakpoint when the program counter reaches address.
+func (cpu *CPU) AddBreakpoint(address uint16) {
+	if cpu.breakpoints == nil {
+		cpu.breakpoints = new([0x10000]bool)
+	}
+	cpu.breakpoints[address] = true
+}
+
+func (cpu *CPU) RemoveBreakpoint(address uint16) {
+	if cpu.breakpoints != nil {
+		cpu.breakpoints[address] = false
+	}
+}
+
+func (cpu *CPU) HasBreakpoint(address uint16) bool {
+	return cpu.breakpoints != nil && cpu.breakpoints[address]
+}
+
+// Breakpoints returns the addresses added with AddBreakpoint, in ascending order.
+func (cpu *CPU) Breakpoints() []uint16 {
+	var breakpoints []uint16
+	if cpu.breakpoints != nil {
+		for address, set := range cpu.breakpoints {
+			if set {
+				breakpoints = append(breakpoints, uint16(address))
+			}
+		}
+	}
+
+	return breakpoints
+}
+
+// WatchKind selects the accesses a watchpoint stops at.
+type WatchKind uint8
+
+const (
+	WatchWrite WatchKind = 1 << iota
+	WatchRead
+
+	WatchAccess = WatchWrite | WatchRead
+)
+
+// watchAccessBit marks an access watchpoint in the watchpoint table. It is kept apart from the read and write bits,
+// so an access watchpoint is removed and reported on its own even when there are others on the same address.
+const watchAccessBit WatchKind = 1 << 2
+
+func (kind WatchKind) bits() WatchKind {
+	if kind == WatchAccess {
+		return watchAccessBit
+	}
+
+	return kind
+}
+
+// AddWatchpoint makes the Run methods stop with StopWatchpoint after an instruction that reads or writes address,
+// as selected by kind. Only the data an instruction works on counts, not its opcode and operand bytes or the dummy
+// accesses of cycle accurate mode. LastWatchpoint tells which watchpoint it was.
+func (cpu *CPU) AddWatchpoint(address uint16, kind WatchKind) {
+	if cpu.watchpoints == nil {
+		cpu.watchpoints = new([0x10000]WatchKind)
+	}
+	cpu.watchpoints[address] |= kind.bits()
+}
+
+func (cpu *CPU) RemoveWatchpoint(address uint16, kind WatchKind) {
+	if cpu.watchpoints != nil {
+		cpu.watchpoints[address] &^= kind.bits()
+	}
+}
+
+// LastWatchpoint returns the address and the kind of the watchpoint that was last hit.
+func (cpu *CPU) LastWatchpoint() (uint16, WatchKind) {
+	return cpu.watchAddress, cpu.watchKind
+}
+
+// watch is called for every data access while there are watchpoints, access is WatchRead or WatchWrite.
+func (cpu *CPU) watch(address uint16, access WatchKind) {
+	set := cpu.watchpoints[address]
+
+	switch {
+	case set&access != 0:
+		cpu.watchKind = access
+	case set&watchAccessBit != 0:
+		cpu.watchKind = WatchAccess
+	default:
+		return
+	}
+
+	cpu.watchHit = true
+	cpu.watchAddress = address
+}
@@ -0,0 +1,192 @@
+package emulator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// runLoop counts up in X forever.
+var runLoop = []uint8{
+	0xE8,             // $0200 INX
+	0x4C, 0x00, 0x02, // $0201 JMP $0200
+}
+
+func TestRunFor(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502, runLoop...)
+
+	reason, err := cpu.RunFor(context.Background(), 100)
+	if reason != StopCycleLimit || err != nil {
+		t.Fatalf("got %v, %v, want the cycle limit", reason, err)
+	}
+	// It only stops between instructions, so it may run over by part of one
+	if cycles := cpu.State().Cycles; cycles < 100 || cycles >= 103 {
+		t.Errorf("stopped after %d cycles, want 100 to 102", cycles)
+	}
+}
+
+func TestRunUntil(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502, runLoop...)
+
+	reason, err := cpu.RunUntil(context.Background(), 0x0201)
+	if reason != StopBreakpoint || err != nil {
+		t.Fatalf("got %v, %v, want the breakpoint", reason, err)
+	}
+	if state := cpu.State(); state.PC != 0x0201 || state.X != 1 {
+		t.Fatalf("stopped at $%04X with X=%d, want $0201 with X=1", state.PC, state.X)
+	}
+
+	// From the address itself it runs at least one instruction, so it goes round the loop once
+	reason, err = cpu.RunUntil(context.Background(), 0x0201)
+	if reason != StopBreakpoint || err != nil {
+		t.Fatalf("got %v, %v, want the breakpoint", reason, err)
+	}
+	if state := cpu.State(); state.PC != 0x0201 || state.X != 2 {
+		t.Errorf("stopped at $%04X with X=%d, want $0201 with X=2", state.PC, state.X)
+	}
+}
+
+func TestRunUntilFunc(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502, runLoop...)
+
+	var calls int
+	reason, err := cpu.RunUntilFunc(context.Background(), func(cpu *CPU) bool {
+		calls++
+		return cpu.State().X == 3
+	})
+	if reason != StopBreakpoint || err != nil {
+		t.Fatalf("got %v, %v, want the breakpoint", reason, err)
+	}
+	// INX, JMP, INX, JMP, INX
+	if state := cpu.State(); state.PC != 0x0201 || state.X != 3 || calls != 5 {
+		t.Errorf("stopped at $%04X with X=%d after %d calls, want $0201 with X=3 after 5", state.PC, state.X, calls)
+	}
+}
+
+func TestRunInstructions(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502, runLoop...)
+
+	reason, err := cpu.RunInstructions(context.Background(), 5)
+	if reason != StopInstructionLimit || err != nil {
+		t.Fatalf("got %v, %v, want the instruction limit", reason, err)
+	}
+	if x := cpu.State().X; x != 3 {
+		t.Errorf("got X=%d after 5 instructions, want 3", x)
+	}
+}
+
+func TestRunInstructionsSkipsStalls(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502, runLoop...)
+	ready := cpu.RDY().NewSource()
+	ready.Assert()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ready.Release()
+	}()
+
+	// The stalled steps run no instruction, so they don't count towards the limit
+	reason, err := cpu.RunInstructions(context.Background(), 1)
+	if reason != StopInstructionLimit || err != nil {
+		t.Fatalf("got %v, %v, want the instruction limit", reason, err)
+	}
+	if state := cpu.State(); state.X != 1 || state.Cycles <= 2 {
+		t.Errorf("got X=%d after %d cycles, want the INX to run after the stall", state.X, state.Cycles)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	cpu, _ := newTestCPU(t, MOS6502, runLoop...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	reason, err := cpu.Run(ctx)
+	if reason != StopCancelled || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, %v, want cancelled", reason, err)
+	}
+}
+
+func TestRunHalted(t *testing.T) {
+	// INX, JAM
+	cpu, _ := newTestCPU(t, MOS6502, 0xE8, 0x02)
+
+	reason, err := cpu.Run(context.Background())
+	if reason != StopHalted || !errors.Is(err, ErrHalted) {
+		t.Errorf("got %v, %v, want halted", reason, err)
+	}
+	if x := cpu.State().X; x != 1 {
+		t.Errorf("got X=%d, want the INX before the JAM to have run", x)
+	}
+}
+
+func TestWatchpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  []uint8
+		x        uint8
+		address  uint16
+		kind     WatchKind
+		hit      bool
+		wantKind WatchKind
+	}{
+		// LDA $1234
+		{"read", []uint8{0xAD, 0x34, 0x12}, 0, 0x1234, WatchRead, true, WatchRead},
+		{"access on a read", []uint8{0xAD, 0x34, 0x12}, 0, 0x1234, WatchAccess, true, WatchAccess},
+		{"write on a read", []uint8{0xAD, 0x34, 0x12}, 0, 0x1234, WatchWrite, false, 0},
+		{"opcode fetch", []uint8{0xAD, 0x34, 0x12}, 0, 0x0200, WatchAccess, false, 0},
+		{"operand fetch", []uint8{0xAD, 0x34, 0x12}, 0, 0x0201, WatchRead, false, 0},
+		// STA $1234
+		{"write", []uint8{0x8D, 0x34, 0x12}, 0, 0x1234, WatchWrite, true, WatchWrite},
+		{"access on a write", []uint8{0x8D, 0x34, 0x12}, 0, 0x1234, WatchAccess, true, WatchAccess},
+		// LDA $10F0,X reads $1010 before fixing up the page
+		{"dummy read", []uint8{0xBD, 0xF0, 0x10}, 0x20, 0x1010, WatchRead, false, 0},
+		{"indexed read", []uint8{0xBD, 0xF0, 0x10}, 0x20, 0x1110, WatchRead, true, WatchRead},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, _ := newTestCPU(t, MOS6502, test.program...)
+			cpu.SetCycleAccurate(true)
+			cpu.registers.X = test.x
+			cpu.AddWatchpoint(test.address, test.kind)
+
+			reason, err := cpu.RunInstructions(context.Background(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if hit := reason == StopWatchpoint; hit != test.hit {
+				t.Fatalf("got %v, want a hit: %v", reason, test.hit)
+			}
+			if !test.hit {
+				return
+			}
+
+			address, kind := cpu.LastWatchpoint()
+			if address != test.address || kind != test.wantKind {
+				t.Errorf("got $%04X kind %d, want $%04X kind %d", address, kind, test.address, test.wantKind)
+			}
+		})
+	}
+}
+
+func TestRemoveAccessWatchpoint(t *testing.T) {
+	// LDA $1234
+	cpu, _ := newTestCPU(t, MOS6502, 0xAD, 0x34, 0x12)
+	cpu.AddWatchpoint(0x1234, WatchRead)
+	cpu.AddWatchpoint(0x1234, WatchAccess)
+	cpu.RemoveWatchpoint(0x1234, WatchAccess)
+
+	reason, err := cpu.RunInstructions(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, kind := cpu.LastWatchpoint(); reason != StopWatchpoint || kind != WatchRead {
+		t.Errorf("got %v with kind %d, want the read watchpoint to stay", reason, kind)
+	}
+}
@@ -0,0 +1,261 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Snapshotter is implemented by devices that have state worth keeping in a save state, beside Memory.
+// Devices that don't implement it, like ROM, are expected to be set up the same way when the state is loaded.
+type Snapshotter interface {
+	// SaveState writes the device's state
+	SaveState(w io.Writer) error
+	// LoadState reads back what SaveState wrote, r ends where the device's state ends
+	LoadState(r io.Reader) error
+}
+
+// Machine is a CPU together with the bus it is connected to.
+type Machine struct {
+	CPU *CPU
+	Bus *Bus
+}
+
+// The save state format starts with a header, followed by the CPU and the state of every memory on the bus,
+// in the order they were added, each prefixed with its length. All values are little endian.
+const saveStateVersion uint16 = 1
+
+var saveStateMagic = [4]byte{'6', '5', '0', '2'}
+
+var ErrInvalidSaveState = errors.New("invalid save state")
+
+type saveStateHeader struct {
+	Magic   [4]byte
+	Version uint16
+	Devices uint16
+}
+
+type cpuSnapshot struct {
+	PC             uint16
+	SP             uint8
+	A              uint8
+	X              uint8
+	Y              uint8
+	P              uint8
+	Cycles         uint64
+	PreviousCycles uint64
+
+	Variant      Variant
+	Undocumented bool
+
+	IRQPending       bool
+	NMIPending       bool
+	NMIEdge          bool // An NMI edge that hasn't been polled yet
+	SOEdge           bool // An SO edge that hasn't been sampled yet
+	InterruptRequest bool // Whether the IRQ raised through ConnectInterrupt is still held
+	ResetHeld        bool
+	Halted           bool
+	Waiting          bool
+}
+
+// SaveState writes the CPU and every memory on the bus that implements Snapshotter to w.
+// The machine must not be running.
+func (m *Machine) SaveState(w io.Writer) error {
+	header := saveStateHeader{
+		Magic:   saveStateMagic,
+		Version: saveStateVersion,
+		Devices: uint16(len(m.Bus.Memory)),
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return err
+	}
+
+	cpu := m.CPU
+	state := cpu.State()
+	snapshot := cpuSnapshot{
+		PC:             state.PC,
+		SP:             state.SP,
+		A:              state.A,
+		X:              state.X,
+		Y:              state.Y,
+		P:              state.P,
+		Cycles:         state.Cycles,
+		PreviousCycles: cpu.previousCycleCount,
+
+		Variant:      cpu.variant,
+		Undocumented: cpu.undocumented,
+
+		IRQPending:       cpu.irqPending,
+		NMIPending:       cpu.nmiPending,
+		NMIEdge:          atomic.LoadUint32(&cpu.nmi.edge) != 0,
+		SOEdge:           atomic.LoadUint32(&cpu.so.edge) != 0,
+		InterruptRequest: cpu.interruptSource != nil && cpu.interruptSource.Asserted(),
+		ResetHeld:        cpu.resetHeld,
+		Halted:           cpu.halted,
+		Waiting:          cpu.waiting,
+	}
+	if err := binary.Write(w, binary.LittleEndian, snapshot); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for i, memory := range m.Bus.Memory {
+		buf.Reset()
+		if snapshotter, ok := memory.(Snapshotter); ok {
+			if err := snapshotter.SaveState(&buf); err != nil {
+				return fmt.Errorf("memory %d: %w", i, err)
+			}
+		}
+
+		if err := binary.Write(w, binary.LittleEndian, uint32(buf.Len())); err != nil {
+			return err
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadState restores a state written by SaveState.
+// The bus must have the same memories, added in the same order, as the machine the state was saved from.
+// The whole state is read and checked before anything is restored, so a truncated or mismatched state leaves
+// the machine as it was.
+func (m *Machine) LoadState(r io.Reader) error {
+	var header saveStateHeader
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return err
+	}
+	if header.Magic != saveStateMagic {
+		return ErrInvalidSaveState
+	}
+	if header.Version != saveStateVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidSaveState, header.Version)
+	}
+	if int(header.Devices) != len(m.Bus.Memory) {
+		return fmt.Errorf("%w: %d memories on the bus, the state has %d", ErrInvalidSaveState, len(m.Bus.Memory), header.Devices)
+	}
+
+	var snapshot cpuSnapshot
+	if err := binary.Read(r, binary.LittleEndian, &snapshot); err != nil {
+		return err
+	}
+	if snapshot.Variant != MOS6502 && snapshot.Variant != WDC65C02 {
+		return fmt.Errorf("%w: unknown variant %d", ErrInvalidSaveState, snapshot.Variant)
+	}
+
+	segments := make([][]byte, len(m.Bus.Memory))
+	for i, memory := range m.Bus.Memory {
+		var size uint32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return err
+		}
+
+		if _, ok := memory.(Snapshotter); !ok {
+			if size != 0 {
+				return fmt.Errorf("%w: memory %d has no state to load", ErrInvalidSaveState, i)
+			}
+			continue
+		}
+
+		// Read through a limit, so a corrupt length can't make us allocate gigabytes up front
+		data, err := io.ReadAll(io.LimitReader(r, int64(size)))
+		if err != nil {
+			return err
+		}
+		if len(data) != int(size) {
+			return io.ErrUnexpectedEOF
+		}
+		segments[i] = data
+	}
+
+	if err := m.loadDevices(segments); err != nil {
+		return err
+	}
+
+	cpu := m.CPU
+	if cpu.variant != snapshot.Variant || cpu.undocumented != snapshot.Undocumented {
+		cpu.variant = snapshot.Variant
+		cpu.undocumented = snapshot.Undocumented
+		cpu.buildDecodeTable()
+	}
+
+	cpu.SetState(State{
+		PC:     snapshot.PC,
+		SP:     snapshot.SP,
+		A:      snapshot.A,
+		X:      snapshot.X,
+		Y:      snapshot.Y,
+		P:      snapshot.P,
+		Cycles: snapshot.Cycles,
+	})
+	cpu.previousCycleCount = snapshot.PreviousCycles
+
+	cpu.irqPending = snapshot.IRQPending
+	cpu.nmiPending = snapshot.NMIPending
+	atomic.StoreUint32(&cpu.nmi.edge, boolToUint32(snapshot.NMIEdge))
+	atomic.StoreUint32(&cpu.so.edge, boolToUint32(snapshot.SOEdge))
+	if cpu.interruptSource != nil {
+		if snapshot.InterruptRequest {
+			cpu.interruptSource.Assert()
+		} else {
+			cpu.interruptSource.Release()
+		}
+	}
+	cpu.resetHeld = snapshot.ResetHeld
+	cpu.halted = snapshot.Halted
+	cpu.waiting = snapshot.Waiting
+
+	// The undo records don't lead back to where the state came from
+	if cpu.history != nil {
+		cpu.history.clear()
+	}
+
+	return nil
+}
+
+// loadDevices hands every Snapshotter on the bus its segment. If one of them rejects its state,
+// the devices that were already loaded are put back the way they were.
+func (m *Machine) loadDevices(segments [][]byte) error {
+	previous := make([][]byte, len(m.Bus.Memory))
+	for i, memory := range m.Bus.Memory {
+		if snapshotter, ok := memory.(Snapshotter); ok {
+			var buf bytes.Buffer
+			if err := snapshotter.SaveState(&buf); err != nil {
+				return fmt.Errorf("memory %d: %w", i, err)
+			}
+			previous[i] = buf.Bytes()
+		}
+	}
+
+	for i, memory := range m.Bus.Memory {
+		snapshotter, ok := memory.(Snapshotter)
+		if !ok {
+			continue
+		}
+
+		if err := snapshotter.LoadState(bytes.NewReader(segments[i])); err != nil {
+			for j := 0; j < i; j++ {
+				if restore, ok := m.Bus.Memory[j].(Snapshotter); ok {
+					restore.LoadState(bytes.NewReader(previous[j]))
+				}
+			}
+
+			return fmt.Errorf("memory %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func boolToUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
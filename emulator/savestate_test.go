@@ -0,0 +1,149 @@
+package emulator
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newSaveStateMachine(sizes ...uint16) (*Machine, []*iRAM) {
+	bus := &Bus{}
+
+	var rams []*iRAM
+	offset := uint16(0)
+	for _, size := range sizes {
+		ram := &iRAM{Offset: offset, Data: make([]uint8, size)}
+		bus.AddMemory(ram)
+		rams = append(rams, ram)
+		offset += size
+	}
+
+	cpu := NewCPU()
+	cpu.ConnectBus(bus)
+
+	return &Machine{CPU: cpu, Bus: bus}, rams
+}
+
+func TestLoadStateLeavesMachineOnError(t *testing.T) {
+	machine, rams := newSaveStateMachine(0x100, 0x100)
+	machine.CPU.SetState(State{PC: 0x1234, A: 0x11})
+	rams[0].Data[0] = 0x01
+	rams[1].Data[0] = 0x02
+
+	var state bytes.Buffer
+	if err := machine.SaveState(&state); err != nil {
+		t.Fatal(err)
+	}
+
+	machine.CPU.SetState(State{PC: 0x4321, A: 0x22})
+	rams[0].Data[0] = 0x03
+	rams[1].Data[0] = 0x04
+
+	unchanged := func() {
+		t.Helper()
+
+		if got := machine.CPU.State(); got.PC != 0x4321 || got.A != 0x22 {
+			t.Errorf("got pc $%04X A=$%02X, want the CPU untouched", got.PC, got.A)
+		}
+		if rams[0].Data[0] != 0x03 || rams[1].Data[0] != 0x04 {
+			t.Errorf("got RAM $%02X $%02X, want $03 $04", rams[0].Data[0], rams[1].Data[0])
+		}
+	}
+
+	// The last segment is cut short
+	truncated := state.Bytes()[:state.Len()-10]
+	if err := machine.LoadState(bytes.NewReader(truncated)); err == nil {
+		t.Error("loaded a truncated state")
+	}
+	unchanged()
+
+	// The second RAM rejects its segment after the first one was loaded
+	corrupt := append([]byte(nil), state.Bytes()...)
+	corrupt[len(corrupt)-0x100-4] = 0x01 // The length becomes $101
+	if err := machine.LoadState(bytes.NewReader(append(corrupt, 0))); !errors.Is(err, ErrInvalidSaveState) {
+		t.Errorf("got %v loading a corrupt state, want ErrInvalidSaveState", err)
+	}
+	unchanged()
+
+	if err := machine.LoadState(bytes.NewReader(state.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if got := machine.CPU.State(); got.PC != 0x1234 || got.A != 0x11 {
+		t.Errorf("got pc $%04X A=$%02X, want $1234 A=$11", got.PC, got.A)
+	}
+	if rams[0].Data[0] != 0x01 || rams[1].Data[0] != 0x02 {
+		t.Errorf("got RAM $%02X $%02X, want $01 $02", rams[0].Data[0], rams[1].Data[0])
+	}
+}
+
+func TestSaveStateRoundTrip(t *testing.T) {
+	newMachine := func() (*Machine, Memory) {
+		machine, _ := newSaveStateMachine(0x100)
+		io := NewIO(0x0100, nil, nil)
+		machine.Bus.AddMemory(io)
+		return machine, io
+	}
+
+	machine, io := newMachine()
+	cpu := machine.CPU
+	cpu.SetVariant(WDC65C02)
+	cpu.SetState(State{PC: 0x1234, SP: 0xAB, A: 0x11, X: 0x22, Y: 0x33, P: 0xFF, Cycles: 123456789})
+	cpu.irqPending = true
+	cpu.nmiPending = true
+	cpu.halted = true
+	cpu.waiting = true
+	io.(*iIO).Input(0x41)
+	io.(*iIO).Input(0x42)
+
+	var state bytes.Buffer
+	if err := machine.SaveState(&state); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, loadedIO := newMachine()
+	if err := loaded.LoadState(bytes.NewReader(state.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := loaded.CPU.State(), cpu.State(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	got := loaded.CPU
+	if got.variant != WDC65C02 {
+		t.Errorf("got the %v, want the 65C02", got.variant)
+	}
+	if !got.irqPending || !got.nmiPending || !got.halted || !got.waiting {
+		t.Errorf("got irqPending=%v nmiPending=%v halted=%v waiting=%v, want them all set",
+			got.irqPending, got.nmiPending, got.halted, got.waiting)
+	}
+	if a, b := loadedIO.Read(0x0100), loadedIO.Read(0x0100); a != 0x41 || b != 0x42 {
+		t.Errorf("read $%02X $%02X from the IO, want the buffered $41 $42", a, b)
+	}
+}
+
+func TestLoadStateHeader(t *testing.T) {
+	machine, _ := newSaveStateMachine(0x100)
+	var state bytes.Buffer
+	if err := machine.SaveState(&state); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+		data   uint8
+	}{
+		{"bad magic", 0, 'X'},
+		{"bad version", 4, 0x02},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			corrupt := append([]byte(nil), state.Bytes()...)
+			corrupt[test.offset] = test.data
+			if err := machine.LoadState(bytes.NewReader(corrupt)); !errors.Is(err, ErrInvalidSaveState) {
+				t.Errorf("got %v, want ErrInvalidSaveState", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package emulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The single step tests are the per opcode JSON test vectors from https://github.com/SingleStepTests/65x02.
+// Every file holds a list of tests, each test describes the machine before and after executing one instruction
+// together with every bus access the instruction made.
+
+type SingleStepState struct {
+	PC  uint16      `json:"pc"`
+	S   uint8       `json:"s"`
+	A   uint8       `json:"a"`
+	X   uint8       `json:"x"`
+	Y   uint8       `json:"y"`
+	P   uint8       `json:"p"`
+	RAM [][2]uint16 `json:"ram"` // [address, value] pairs
+}
+
+// UnmarshalJSON decodes the [address, value, "read"|"write"] triples used by the test vectors.
+func (b *BusAccess) UnmarshalJSON(data []byte) error {
+	var raw [3]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	address, ok := raw[0].(float64)
+	if !ok {
+		return fmt.Errorf("invalid bus address: %v", raw[0])
+	}
+	value, ok := raw[1].(float64)
+	if !ok {
+		return fmt.Errorf("invalid bus value: %v", raw[1])
+	}
+
+	b.Address = uint16(address)
+	b.Data = uint8(value)
+
+	switch raw[2] {
+	case "read":
+		b.Write = false
+	case "write":
+		b.Write = true
+	default:
+		return fmt.Errorf("invalid bus access type: %v", raw[2])
+	}
+
+	return nil
+}
+
+type SingleStepTest struct {
+	Name    string          `json:"name"`
+	Initial SingleStepState `json:"initial"`
+	Final   SingleStepState `json:"final"`
+	Cycles  []BusAccess     `json:"cycles"`
+}
+
+func LoadSingleStepTests(r io.Reader) ([]SingleStepTest, error) {
+	var tests []SingleStepTest
+	if err := json.NewDecoder(r).Decode(&tests); err != nil {
+		return nil, err
+	}
+
+	return tests, nil
+}
+
+// busRecorder is a 64KB RAM that keeps a log of every access made to it.
+type busRecorder struct {
+	Data [1 << 16]uint8
+	Log  []BusAccess
+}
+
+func (r *busRecorder) Read(address uint16) uint8 {
+	r.Log = append(r.Log, BusAccess{Address: address, Data: r.Data[address]})
+	return r.Data[address]
+}
+
+func (r *busRecorder) Write(address uint16, data uint8) {
+	r.Log = append(r.Log, BusAccess{Address: address, Data: data, Write: true})
+	r.Data[address] = data
+}
+
+func (r *busRecorder) Contains(address uint16) bool {
+	return true
+}
+
+// The B flag and bit 5 only exist on the stack, not in the status register.
+const singleStepFlagMask = 0xCF
+
+// RunSingleStepTest executes the instruction described by the test on the given variant and compares the result
+// with the expected state. If checkBus is set the instruction runs in cycle accurate mode, and the bus accesses
+// it made must also match the expected ones exactly.
+func RunSingleStepTest(test SingleStepTest, variant Variant, checkBus bool) error {
+	recorder := &busRecorder{}
+	for _, entry := range test.Initial.RAM {
+		recorder.Data[entry[0]] = uint8(entry[1])
+	}
+
+	bus := &Bus{}
+	bus.AddMemory(recorder)
+
+	cpu := NewCPU()
+	cpu.SetVariant(variant)
+	cpu.ConnectBus(bus)
+	cpu.SetCycleAccurate(checkBus)
+
+	cpu.SetState(State{
+		PC: test.Initial.PC,
+		SP: test.Initial.S,
+		A:  test.Initial.A,
+		X:  test.Initial.X,
+		Y:  test.Initial.Y,
+		P:  test.Initial.P,
+	})
+
+	if err := cpu.Step(); err != nil {
+		return fmt.Errorf("%s: %w", test.Name, err)
+	}
+
+	state := cpu.State()
+
+	var errs []string
+	check := func(name string, got, want uint16) {
+		if got != want {
+			errs = append(errs, fmt.Sprintf("%s: got $%02x, want $%02x", name, got, want))
+		}
+	}
+
+	check("pc", state.PC, test.Final.PC)
+	check("s", uint16(state.SP), uint16(test.Final.S))
+	check("a", uint16(state.A), uint16(test.Final.A))
+	check("x", uint16(state.X), uint16(test.Final.X))
+	check("y", uint16(state.Y), uint16(test.Final.Y))
+	check("p", uint16(state.P&singleStepFlagMask), uint16(test.Final.P&singleStepFlagMask))
+
+	for _, entry := range test.Final.RAM {
+		check(fmt.Sprintf("ram[$%04x]", entry[0]), uint16(recorder.Data[entry[0]]), entry[1])
+	}
+
+	if checkBus {
+		if len(recorder.Log) != len(test.Cycles) {
+			errs = append(errs, fmt.Sprintf("bus: got %d accesses, want %d", len(recorder.Log), len(test.Cycles)))
+		}
+
+		for i := 0; i < len(recorder.Log) && i < len(test.Cycles); i++ {
+			if recorder.Log[i] != test.Cycles[i] {
+				errs = append(errs, fmt.Sprintf("bus cycle %d: got %v, want %v", i, recorder.Log[i], test.Cycles[i]))
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%s: %s", test.Name, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// singleStepDir is where the vectors are looked for, one folder per variant. SINGLESTEP_TESTS overrides it.
+func singleStepDir() string {
+	if dir := os.Getenv("SINGLESTEP_TESTS"); dir != "" {
+		return dir
+	}
+
+	return filepath.Join("testdata", "singlestep")
+}
+
+func TestSingleStep(t *testing.T) {
+	for _, variant := range []struct {
+		variant Variant
+		folder  string
+	}{
+		{MOS6502, "6502"},
+		{WDC65C02, "65c02"},
+	} {
+		variant := variant
+		t.Run(variant.variant.String(), func(t *testing.T) {
+			dir := filepath.Join(singleStepDir(), variant.folder)
+
+			files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) == 0 {
+				t.Fatalf("no single step vectors in %s, see testdata/README.md", dir)
+			}
+
+			for _, file := range files {
+				file := file
+				t.Run(filepath.Base(file), func(t *testing.T) {
+					runSingleStepFile(t, file, variant.variant)
+				})
+			}
+		})
+	}
+}
+
+func runSingleStepFile(t *testing.T, file string, variant Variant) {
+	// Stop reporting a file after this many failing vectors
+	const maxFailures = 10
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests, err := LoadSingleStepTests(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failures := 0
+	for _, test := range tests {
+		if err := RunSingleStepTest(test, variant, true); err != nil {
+			t.Error(err)
+
+			if failures++; failures == maxFailures {
+				t.Fatalf("stopping after %d failures", failures)
+			}
+		}
+	}
+}
@@ -19,7 +19,8 @@ type Flags struct {
 	Zero             bool // Zero flag
 	InterruptDisable bool // Interrupt disable flag
 	Decimal          bool // Decimal mode flag
-	BreakCommand     bool // Break command flag
+	BreakCommand     bool // Break command flag, only exists in the copy of the flags pushed by BRK and PHP
+	Unused           bool // Bit 5, has no meaning to the CPU and always reads as 1
 	Overflow         bool // Overflow flag
 	Negative         bool // Negative flag
 }
@@ -41,12 +42,15 @@ func (f Flags) ToByte() uint8 {
 	if f.BreakCommand {
 		b |= 0x10
 	}
-	if f.Overflow {
+	if f.Unused {
 		b |= 0x20
 	}
-	if f.Negative {
+	if f.Overflow {
 		b |= 0x40
 	}
+	if f.Negative {
+		b |= 0x80
+	}
 
 	return b
 }
@@ -57,6 +61,25 @@ func (f *Flags) FromByte(b uint8) {
 	f.InterruptDisable = b&0x04 != 0
 	f.Decimal = b&0x08 != 0
 	f.BreakCommand = b&0x10 != 0
-	f.Overflow = b&0x20 != 0
-	f.Negative = b&0x40 != 0
+	f.Unused = b&0x20 != 0
+	f.Overflow = b&0x40 != 0
+	f.Negative = b&0x80 != 0
+}
+
+// StackByte returns the flags as they are pushed to the stack.
+// Bit 5 is always set, B is set when pushed by BRK or PHP and clear when pushed by an IRQ or NMI.
+func (f Flags) StackByte(brk bool) uint8 {
+	b := f.ToByte() | 0x20
+	if brk {
+		return b | 0x10
+	}
+
+	return b &^ 0x10
+}
+
+// FromStackByte restores the flags pulled by PLP or RTI, B and bit 5 are not real flags so they keep their value.
+func (f *Flags) FromStackByte(b uint8) {
+	breakCommand, unused := f.BreakCommand, f.Unused
+	f.FromByte(b)
+	f.BreakCommand, f.Unused = breakCommand, unused
 }
@@ -0,0 +1,36 @@
+package emulator
+
+// State is a snapshot of the programmer visible state of the CPU.
+type State struct {
+	PC uint16 // Program counter
+	SP uint8  // Stack pointer
+	A  uint8  // Accumulator
+	X  uint8  // Index register X
+	Y  uint8  // Index register Y
+	P  uint8  // Processor status, as pushed to the stack (NV-BDIZC)
+
+	Cycles uint64 // The number of cycles that have passed
+}
+
+func (cpu *CPU) State() State {
+	return State{
+		PC:     cpu.programCounter,
+		SP:     uint8(cpu.stackPointer),
+		A:      cpu.registers.A,
+		X:      cpu.registers.X,
+		Y:      cpu.registers.Y,
+		P:      cpu.flags.ToByte(),
+		Cycles: cpu.cycleCount,
+	}
+}
+
+func (cpu *CPU) SetState(state State) {
+	cpu.programCounter = state.PC
+	cpu.stackPointer = StackPointer(state.SP)
+	cpu.registers.A = state.A
+	cpu.registers.X = state.X
+	cpu.registers.Y = state.Y
+	cpu.flags.FromByte(state.P)
+	cpu.cycleCount = state.Cycles
+	cpu.previousCycleCount = state.Cycles
+}
@@ -0,0 +1,38 @@
+package emulator
+
+import "testing"
+
+func TestStateRoundTrip(t *testing.T) {
+	cpu, ram := newTestCPU(t, MOS6502)
+
+	for p := 0; p < 0x100; p++ {
+		want := State{
+			PC:     0x1234 + uint16(p),
+			SP:     uint8(0xFF - p),
+			A:      uint8(p),
+			X:      uint8(p ^ 0x55),
+			Y:      uint8(p ^ 0xAA),
+			P:      uint8(p),
+			Cycles: uint64(p) << 20,
+		}
+
+		cpu.SetState(want)
+		if got := cpu.State(); got != want {
+			t.Fatalf("P=$%02X: got %+v, want %+v", p, got, want)
+		}
+	}
+
+	// B and bit 5 only live in the register, PHP always pushes both set
+	for _, p := range []uint8{0x00, 0x10, 0x20, 0xCF} {
+		ram[0x0200] = 0x08 // PHP
+		cpu.SetState(State{PC: 0x0200, SP: 0xFF, P: p})
+		step(t, cpu)
+
+		if got := ram[0x01FF]; got != p|0x30 {
+			t.Errorf("PHP with P=$%02X pushed $%02X, want $%02X", p, got, p|0x30)
+		}
+		if got := cpu.State().P; got != p {
+			t.Errorf("PHP changed P from $%02X to $%02X", p, got)
+		}
+	}
+}
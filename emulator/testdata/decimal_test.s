@@ -0,0 +1,276 @@
+; Verify decimal mode behavior
+; Written by Bruce Clark. This code is public domain.
+; From the 6502.org tutorial "Decimal Mode", Appendix B: http://www.6502.org/tutorials/decimal_mode.html
+;
+; Adapted to run under RunFunctionalTest: the test starts at $0200 and traps at done when it is over,
+; ERROR ($0B) is 0 if the test passed, 1 if it failed. Assemble with CMOS = 0 for the 6502 predictions
+; of the flags, CMOS = 1 for the 65C02 ones.
+;
+; N1 and N2 are the two numbers to be added or subtracted
+; N1H, N1L, N2H and N2L are the upper 4 bits and lower 4 bits of N1 and N2
+; DA and DNVZC are the actual accumulator and flag results in decimal mode
+; HA and HNVZC are the accumulator and flag results when N1 and N2 are
+;   added or subtracted using binary arithmetic
+; AR, NF, VF, ZF and CF are the predicted decimal mode accumulator and
+;   flag results, calculated using binary arithmetic
+
+N1 = $00
+N2 = $01
+HA = $02
+HNVZC = $03
+DA = $04
+DNVZC = $05
+AR = $06
+NF = $07
+VF = $08
+ZF = $09
+CF = $0A
+ERROR = $0B
+N1L = $0C
+N1H = $0D
+N2L = $0E
+N2H = $0F ; 2 bytes
+
+	.org $0200
+
+start:
+	jsr test
+done:
+	jmp done ; trap, ERROR tells whether the test passed
+
+test:
+	ldy #1 ; initialize Y (used to loop through carry flag values)
+	sty ERROR ; store 1 in ERROR until the test passes
+	lda #0 ; initialize N1 and N2
+	sta N1
+	sta N2
+loop1:
+	lda N2 ; N2L = N2 & $0F
+	and #$0F
+	sta N2L
+	lda N2 ; N2H = N2 & $F0
+	and #$F0
+	sta N2H
+	ora #$0F ; N2H+1 = (N2 & $F0) + $0F
+	sta N2H+1
+loop2:
+	lda N1 ; N1L = N1 & $0F
+	and #$0F
+	sta N1L
+	lda N1 ; N1H = N1 & $F0
+	and #$F0
+	sta N1H
+	jsr add
+	.if CMOS
+	jsr a65c02
+	.else
+	jsr a6502
+	.endif
+	jsr compare
+	bne test_done
+	jsr sub
+	.if CMOS
+	jsr s65c02
+	.else
+	jsr s6502
+	.endif
+	jsr compare
+	bne test_done
+	inc N1
+	bne loop2 ; loop through all 256 values of N1
+	inc N2
+	bne loop1 ; loop through all 256 values of N2
+	dey
+	bpl loop1 ; loop through both values of the carry flag
+	lda #0 ; test passed, so store 0 in ERROR
+	sta ERROR
+test_done:
+	rts
+
+; Calculate the actual decimal mode accumulator and flags, the accumulator
+; and flag results when N1 is added to N2 using binary arithmetic, the
+; predicted accumulator result, the predicted carry flag, and the predicted
+; V flag
+add:
+	sed ; decimal mode
+	cpy #1 ; set carry if Y = 1, clear carry if Y = 0
+	lda N1
+	adc N2
+	sta DA ; actual accumulator result in decimal mode
+	php
+	pla
+	sta DNVZC ; actual flags result in decimal mode
+	cld ; binary mode
+	cpy #1 ; set carry if Y = 1, clear carry if Y = 0
+	lda N1
+	adc N2
+	sta HA ; accumulator result of N1+N2 using binary arithmetic
+	php
+	pla
+	sta HNVZC ; flags result of N1+N2 using binary arithmetic
+	cpy #1
+	lda N1L
+	adc N2L
+	cmp #$0A
+	ldx #0
+	bcc a1
+	inx
+	adc #5 ; add 6 (carry is set)
+	and #$0F
+	sec
+a1:
+	ora N1H
+; if N1L + N2L <  $0A, then add N2 & $F0
+; if N1L + N2L >= $0A, then add (N2 & $F0) + $0F + 1 (carry is set)
+	adc N2H,x
+	php
+	bcs a2
+	cmp #$A0
+	bcc a3
+a2:
+	adc #$5F ; add $60 (carry is set)
+	sec
+a3:
+	sta AR ; predicted accumulator result
+	php
+	pla
+	sta CF ; predicted carry result
+	pla
+; note that all 8 bits of the P register are stored in VF
+	sta VF ; predicted V flags
+	rts
+
+; Calculate the actual decimal mode accumulator and flags, and the
+; accumulator and flag results when N2 is subtracted from N1 using binary
+; arithmetic
+sub:
+	sed ; decimal mode
+	cpy #1 ; set carry if Y = 1, clear carry if Y = 0
+	lda N1
+	sbc N2
+	sta DA ; actual accumulator result in decimal mode
+	php
+	pla
+	sta DNVZC ; actual flags result in decimal mode
+	cld ; binary mode
+	cpy #1 ; set carry if Y = 1, clear carry if Y = 0
+	lda N1
+	sbc N2
+	sta HA ; accumulator result of N1-N2 using binary arithmetic
+	php
+	pla
+	sta HNVZC ; flags result of N1-N2 using binary arithmetic
+	rts
+
+; Calculate the predicted SBC accumulator result for the 6502
+sub1:
+	cpy #1 ; set carry if Y = 1, clear carry if Y = 0
+	lda N1L
+	sbc N2L
+	ldx #0
+	bcs s11
+	inx
+	sbc #5 ; subtract 6 (carry is clear)
+	and #$0F
+	clc
+s11:
+	ora N1H
+; if N1L - N2L >= 0, then subtract N2 & $F0
+; if N1L - N2L <  0, then subtract (N2 & $F0) + $0F + 1 (carry is clear)
+	sbc N2H,x
+	bcs s12
+	sbc #$5F ; subtract $60 (carry is clear)
+s12:
+	sta AR
+	rts
+
+; Calculate the predicted SBC accumulator result for the 65C02
+sub2:
+	cpy #1 ; set carry if Y = 1, clear carry if Y = 0
+	lda N1L
+	sbc N2L
+	ldx #0
+	bcs s21
+	inx
+	and #$0F
+	clc
+s21:
+	ora N1H
+; if N1L - N2L >= 0, then subtract N2 & $F0
+; if N1L - N2L <  0, then subtract (N2 & $F0) + $0F + 1 (carry is clear)
+	sbc N2H,x
+	bcs s22
+	sbc #$5F ; subtract $60 (carry is clear)
+s22:
+	cpx #0
+	beq s23
+	sbc #6
+s23:
+	sta AR ; predicted accumulator result
+	rts
+
+; Compare accumulator actual results to predicted results
+; Return:
+;   Z flag = 1 (BEQ branch) if same
+;   Z flag = 0 (BNE branch) if different
+compare:
+	lda DA
+	cmp AR
+	bne c1
+	lda DNVZC
+	eor NF
+	and #$80 ; mask off N flag
+	bne c1
+	lda DNVZC
+	eor VF
+	and #$40 ; mask off V flag
+	bne c1
+	lda DNVZC
+	eor ZF ; mask off Z flag
+	and #2
+	bne c1
+	lda DNVZC
+	eor CF
+	and #1 ; mask off C flag
+c1:
+	rts
+
+; These routines store the predicted values for ADC and SBC for the 6502
+; and 65C02 in AR, CF, NF, VF and ZF
+a6502:
+	lda VF
+; since all 8 bits of the P register were stored in VF, bit 7 of VF contains
+; the N flag for NF
+	sta NF
+	lda HNVZC
+	sta ZF
+	rts
+
+s6502:
+	jsr sub1
+	lda HNVZC
+	sta NF
+	sta VF
+	sta ZF
+	sta CF
+	rts
+
+a65c02:
+	lda AR
+	php
+	pla
+	sta NF
+	sta ZF
+	rts
+
+s65c02:
+	jsr sub2
+	lda AR
+	php
+	pla
+	sta NF
+	sta ZF
+	lda HNVZC
+	sta VF
+	sta CF
+	rts
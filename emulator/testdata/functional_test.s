@@ -0,0 +1,2681 @@
+; Functional test of the documented NMOS 6502 instructions, written for this emulator.
+; Every test stores its number in test_case and traps (jumps to itself) on the first check that fails.
+; The program traps at success once every test passed.
+;
+; Assemble with vasm6502_oldstyle: vasm6502_oldstyle -Fbin -dotdir functional_test.s
+; and load the 64KB image at $0000, execution starts at $0400.
+
+ptr = $10 ; 2 bytes
+tmp = $12
+ptr2 = $30 ; 2 bytes
+test_case = $0200
+
+	.org $0400
+
+start:
+	cld
+	ldx #$FF
+	txs
+
+; Loads set N and Z
+test01:
+	lda #$01
+	sta test_case
+	lda #$F7
+	pha
+	plp
+	lda #$00
+	php
+	cmp #$00
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	cmp #$77
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$02
+	pha
+	plp
+	lda #$80
+	php
+	cmp #$80
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$82
+	pha
+	plp
+	lda #$01
+	php
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$00
+	pha
+	plp
+	ldx #$00
+	php
+	pla
+	cmp #$32
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldx #$FF
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldy #$00
+	php
+	pla
+	cmp #$32
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldy #$7F
+	php
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+
+; Transfers set N and Z, TXS does not
+test02:
+	lda #$02
+	sta test_case
+	lda #$00
+	pha
+	plp
+	lda #$80
+	tax
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cpx #$80
+	beq *+5
+	jmp * ; trap: failed
+	lda #$00
+	tay
+	php
+	pla
+	cmp #$33
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldx #$7F
+	txa
+	php
+	cmp #$7F
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	cmp #$31
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldy #$FF
+	tya
+	php
+	cmp #$FF
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	cmp #$B1
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$02
+	pha
+	plp
+	ldx #$80
+	txs
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	tsx
+	cpx #$80
+	beq *+5
+	jmp * ; trap: failed
+	ldx #$FF
+	txs
+
+; Flag instructions
+test03:
+	lda #$03
+	sta test_case
+	lda #$00
+	pha
+	plp
+	sec
+	php
+	pla
+	cmp #$31
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	clc
+	php
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	sei
+	php
+	pla
+	cmp #$34
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cli
+	php
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	sed
+	php
+	pla
+	cmp #$38
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cld
+	php
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$40
+	pha
+	plp
+	clv
+	php
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+
+; ADC (zp),y against a table of (A, operand, carry in, result, NV--ZC)
+test04:
+	lda #$04
+	sta test_case
+	lda #<adc_table
+	sta ptr
+	lda #>adc_table
+	sta ptr+1
+adc_loop1:
+	ldy #2
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	ldy #1
+	adc (ptr),y
+	php
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #4
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #5
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<adc_end
+	bne adc_loop1
+	lda ptr+1
+	cmp #>adc_end
+	bne adc_loop1
+
+; SBC (zp),y against a table of (A, operand, carry in, result, NV--ZC)
+test05:
+	lda #$05
+	sta test_case
+	lda #<sbc_table
+	sta ptr
+	lda #>sbc_table
+	sta ptr+1
+sbc_loop2:
+	ldy #2
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	ldy #1
+	sbc (ptr),y
+	php
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #4
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #5
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<sbc_end
+	bne sbc_loop2
+	lda ptr+1
+	cmp #>sbc_end
+	bne sbc_loop2
+
+; CMP (zp),y against a table of (A, operand, carry in, result, NV--ZC)
+test06:
+	lda #$06
+	sta test_case
+	lda #<cmp_table
+	sta ptr
+	lda #>cmp_table
+	sta ptr+1
+cmp_loop3:
+	ldy #2
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	ldy #1
+	cmp (ptr),y
+	php
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #4
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #5
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<cmp_end
+	bne cmp_loop3
+	lda ptr+1
+	cmp #>cmp_end
+	bne cmp_loop3
+
+; AND (zp),y against a table of (A, operand, carry in, result, NV--ZC)
+test07:
+	lda #$07
+	sta test_case
+	lda #<and_table
+	sta ptr
+	lda #>and_table
+	sta ptr+1
+and_loop4:
+	ldy #2
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	ldy #1
+	and (ptr),y
+	php
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #4
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #5
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<and_end
+	bne and_loop4
+	lda ptr+1
+	cmp #>and_end
+	bne and_loop4
+
+; ORA (zp),y against a table of (A, operand, carry in, result, NV--ZC)
+test08:
+	lda #$08
+	sta test_case
+	lda #<ora_table
+	sta ptr
+	lda #>ora_table
+	sta ptr+1
+ora_loop5:
+	ldy #2
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	ldy #1
+	ora (ptr),y
+	php
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #4
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #5
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<ora_end
+	bne ora_loop5
+	lda ptr+1
+	cmp #>ora_end
+	bne ora_loop5
+
+; EOR (zp),y against a table of (A, operand, carry in, result, NV--ZC)
+test09:
+	lda #$09
+	sta test_case
+	lda #<eor_table
+	sta ptr
+	lda #>eor_table
+	sta ptr+1
+eor_loop6:
+	ldy #2
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	ldy #1
+	eor (ptr),y
+	php
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #4
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #5
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<eor_end
+	bne eor_loop6
+	lda ptr+1
+	cmp #>eor_end
+	bne eor_loop6
+
+; CPX and CPY when equal, lower and higher
+test10:
+	lda #$0A
+	sta test_case
+	ldx #$40
+	cpx #$40
+	php
+	pla
+	cmp #$33
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cpx #$41
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cpx #$3F
+	php
+	pla
+	cmp #$31
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$40
+	sta tmp
+	cpx tmp
+	php
+	pla
+	cmp #$33
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cpx $0000+tmp
+	php
+	pla
+	cmp #$33
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldy #$40
+	cpy #$40
+	php
+	pla
+	cmp #$33
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cpy #$41
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cpy #$3F
+	php
+	pla
+	cmp #$31
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$40
+	sta tmp
+	cpy tmp
+	php
+	pla
+	cmp #$33
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	cpy $0000+tmp
+	php
+	pla
+	cmp #$33
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+
+; ASL A and ASL zp against a table of (A, carry in, result, flags)
+test11:
+	lda #$0B
+	sta test_case
+	lda #<asl_table
+	sta ptr
+	lda #>asl_table
+	sta ptr+1
+asl_loop7:
+	ldy #1
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	asl a
+	php
+	ldy #2
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	ldy #1
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	sta tmp
+	asl tmp
+	php
+	lda tmp
+	ldy #2
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #4
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<asl_end
+	bne asl_loop7
+	lda ptr+1
+	cmp #>asl_end
+	bne asl_loop7
+
+; LSR A and LSR zp against a table of (A, carry in, result, flags)
+test12:
+	lda #$0C
+	sta test_case
+	lda #<lsr_table
+	sta ptr
+	lda #>lsr_table
+	sta ptr+1
+lsr_loop8:
+	ldy #1
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	lsr a
+	php
+	ldy #2
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	ldy #1
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	sta tmp
+	lsr tmp
+	php
+	lda tmp
+	ldy #2
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #4
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<lsr_end
+	bne lsr_loop8
+	lda ptr+1
+	cmp #>lsr_end
+	bne lsr_loop8
+
+; ROL A and ROL zp against a table of (A, carry in, result, flags)
+test13:
+	lda #$0D
+	sta test_case
+	lda #<rol_table
+	sta ptr
+	lda #>rol_table
+	sta ptr+1
+rol_loop9:
+	ldy #1
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	rol a
+	php
+	ldy #2
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	ldy #1
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	sta tmp
+	rol tmp
+	php
+	lda tmp
+	ldy #2
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #4
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<rol_end
+	bne rol_loop9
+	lda ptr+1
+	cmp #>rol_end
+	bne rol_loop9
+
+; ROR A and ROR zp against a table of (A, carry in, result, flags)
+test14:
+	lda #$0E
+	sta test_case
+	lda #<ror_table
+	sta ptr
+	lda #>ror_table
+	sta ptr+1
+ror_loop10:
+	ldy #1
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	ror a
+	php
+	ldy #2
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	ldy #1
+	lda (ptr),y
+	lsr a ; carry in
+	clv
+	ldy #0
+	lda (ptr),y
+	sta tmp
+	ror tmp
+	php
+	lda tmp
+	ldy #2
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	and #$C3
+	ldy #3
+	cmp (ptr),y
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	lda ptr
+	adc #4
+	sta ptr
+	bcc *+4
+	inc ptr+1
+	cmp #<ror_end
+	bne ror_loop10
+	lda ptr+1
+	cmp #>ror_end
+	bne ror_loop10
+
+; Increments and decrements wrap
+test15:
+	lda #$0F
+	sta test_case
+	lda #$00
+	pha
+	plp
+	ldx #$FF
+	inx
+	php
+	pla
+	cmp #$32
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	dex
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldy #$7F
+	iny
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldy #$01
+	dey
+	php
+	pla
+	cmp #$32
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$FF
+	sta tmp
+	inc tmp
+	php
+	pla
+	cmp #$32
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	php
+	lda tmp
+	cmp #$00
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	dec tmp
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	php
+	lda tmp
+	cmp #$FF
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	lda #$7F
+	sta $1300
+	inc $1300
+	php
+	pla
+	cmp #$B0
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	php
+	lda $1300
+	cmp #$80
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	ldx #$10
+	dec $12F0,x
+	php
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	php
+	lda $1300
+	cmp #$7F
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	inc tmp-$10,x
+	php
+	lda tmp
+	cmp #$00
+	beq *+5
+	jmp * ; trap: failed
+	plp
+
+; BIT copies N and V and tests A
+test16:
+	lda #$10
+	sta test_case
+	lda #$C0
+	sta tmp
+	lda #$00
+	pha
+	plp
+	lda #$00
+	bit tmp
+	php
+	pla
+	cmp #$F2
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$3F
+	sta $1300
+	lda #$C2
+	pha
+	plp
+	lda #$01
+	bit $1300
+	php
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+
+; Addressing modes and their wrap arounds
+test17:
+	lda #$11
+	sta test_case
+	lda #$11
+	sta $20
+	lda #$EE
+	sta $0120
+	ldx #$F0
+	lda $30,x ; zero page,X wraps to $20
+	php
+	cmp #$11
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	ldy #$F0
+	ldx $30,y ; zero page,Y wraps to $20
+	cpx #$11
+	beq *+5
+	jmp * ; trap: failed
+	lda #$5A
+	sta $1310
+	lda #$A5
+	sta $1210
+	ldx #$20
+	lda $12F0,x ; absolute,X crosses into $13
+	cmp #$5A
+	beq *+5
+	jmp * ; trap: failed
+	ldy #$20
+	lda $12F0,y ; absolute,Y crosses into $13
+	cmp #$5A
+	beq *+5
+	jmp * ; trap: failed
+	lda #$F0
+	sta ptr2
+	lda #$12
+	sta ptr2+1
+	lda (ptr2),y ; (zero page),Y crosses into $13
+	cmp #$5A
+	beq *+5
+	jmp * ; trap: failed
+	lda #$10
+	sta $FF
+	lda #$13
+	sta $00
+	lda #$12
+	sta $0100
+	ldx #$00
+	lda ($FF,x) ; the pointer wraps to $00
+	cmp #$5A
+	beq *+5
+	jmp * ; trap: failed
+	ldx #$7F
+	lda ($80,x) ; $80+$7F is $FF, the pointer wraps to $00
+	cmp #$5A
+	beq *+5
+	jmp * ; trap: failed
+	lda #$F0
+	sta $FF
+	lda #$12
+	sta $00
+	ldy #$20
+	lda ($FF),y ; the pointer wraps to $00
+	cmp #$5A
+	beq *+5
+	jmp * ; trap: failed
+	lda #$33
+	ldx #$F0
+	sta $35,x ; zero page,X store wraps to $25
+	php
+	lda $25
+	cmp #$33
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	lda #$44
+	ldx #$10
+	sta $12F5,x
+	php
+	lda $1305
+	cmp #$44
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	lda #$55
+	ldy #$20
+	sta (ptr2),y
+	php
+	lda $1310
+	cmp #$55
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	lda #$66
+	sta $1300
+	lda #$00
+	sta $1301
+	lda #$13
+	sta $40
+	lda #$00
+	sta $3F
+	ldx #$04
+	lda #$77
+	sta ($3B,x)
+	php
+	lda $1300
+	cmp #$77
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	ldx #$5A
+	ldy #$F0
+	stx $35,y ; zero page,Y store wraps to $25
+	php
+	lda $25
+	cmp #$5A
+	beq *+5
+	jmp * ; trap: failed
+	plp
+	ldy #$A5
+	ldx #$F0
+	sty $35,x
+	php
+	lda $25
+	cmp #$A5
+	beq *+5
+	jmp * ; trap: failed
+	plp
+
+; Branches taken and not taken
+test18:
+	lda #$12
+	sta test_case
+	lda #$00
+	pha
+	plp
+	bcc taken11
+	jmp * ; trap: not taken
+taken11:
+	lda #$01
+	pha
+	plp
+	bcc *+5
+	jmp not_taken12
+	jmp * ; trap: taken
+not_taken12:
+	lda #$01
+	pha
+	plp
+	bcs taken13
+	jmp * ; trap: not taken
+taken13:
+	lda #$00
+	pha
+	plp
+	bcs *+5
+	jmp not_taken14
+	jmp * ; trap: taken
+not_taken14:
+	lda #$00
+	pha
+	plp
+	bne taken15
+	jmp * ; trap: not taken
+taken15:
+	lda #$02
+	pha
+	plp
+	bne *+5
+	jmp not_taken16
+	jmp * ; trap: taken
+not_taken16:
+	lda #$02
+	pha
+	plp
+	beq taken17
+	jmp * ; trap: not taken
+taken17:
+	lda #$00
+	pha
+	plp
+	beq *+5
+	jmp not_taken18
+	jmp * ; trap: taken
+not_taken18:
+	lda #$00
+	pha
+	plp
+	bvc taken19
+	jmp * ; trap: not taken
+taken19:
+	lda #$40
+	pha
+	plp
+	bvc *+5
+	jmp not_taken20
+	jmp * ; trap: taken
+not_taken20:
+	lda #$40
+	pha
+	plp
+	bvs taken21
+	jmp * ; trap: not taken
+taken21:
+	lda #$00
+	pha
+	plp
+	bvs *+5
+	jmp not_taken22
+	jmp * ; trap: taken
+not_taken22:
+	lda #$00
+	pha
+	plp
+	bpl taken23
+	jmp * ; trap: not taken
+taken23:
+	lda #$80
+	pha
+	plp
+	bpl *+5
+	jmp not_taken24
+	jmp * ; trap: taken
+not_taken24:
+	lda #$80
+	pha
+	plp
+	bmi taken25
+	jmp * ; trap: not taken
+taken25:
+	lda #$00
+	pha
+	plp
+	bmi *+5
+	jmp not_taken26
+	jmp * ; trap: taken
+not_taken26:
+	jmp page_branches
+page_branches_done:
+
+; The stack, PHP pushes B and bit 5, PLP ignores them
+test19:
+	lda #$13
+	sta test_case
+	lda #$CF
+	pha
+	plp
+	php
+	pla
+	cmp #$FF
+	beq *+5
+	jmp * ; trap: failed
+	cld
+	lda #$30
+	pha
+	plp
+	php
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	tsx
+	cpx #$FF
+	beq *+5
+	jmp * ; trap: failed
+	lda #$12
+	pha
+	lda #$34
+	pha
+	tsx
+	cpx #$FD
+	beq *+5
+	jmp * ; trap: failed
+	lda $01FF
+	cmp #$12
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	cmp #$34
+	beq *+5
+	jmp * ; trap: failed
+	clc
+	pla
+	php
+	cmp #$12
+	beq *+5
+	jmp * ; trap: failed
+	pla
+	cmp #$30
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+
+; JSR pushes the address of its last byte, RTS and RTI return
+test20:
+	lda #$14
+	sta test_case
+	jsr check_return
+return_point:
+	tsx
+	cpx #$FF
+	beq *+5
+	jmp * ; trap: failed
+	lda #>rti_target
+	pha
+	lda #<rti_target
+	pha
+	lda #$C3
+	pha
+	rti
+	jmp * ; trap: RTI did not jump
+rti_target:
+	php
+	pla
+	cmp #$F3
+	beq *+5
+	jmp * ; trap: failed
+	pha
+	plp
+	lda #$00
+	pha
+	plp
+
+; JMP indirect does not carry into the high byte of the vector address
+test21:
+	lda #$15
+	sta test_case
+	lda #<jmp_target
+	sta $13FF
+	lda #>jmp_target
+	sta $1300
+	lda #>jmp_wrong
+	sta $1400
+	jmp ($13FF)
+	jmp * ; trap: JMP did not jump
+jmp_done:
+
+; BRK pushes PC+2 and P with B set, sets I and goes through $FFFE
+test22:
+	lda #$16
+	sta test_case
+	lda #$00
+	pha
+	plp
+	lda #0
+	sta tmp
+	brk
+	.byte $EA ; the signature byte BRK skips
+brk_return:
+	lda tmp
+	cmp #1
+	beq *+5
+	jmp * ; trap: failed
+	php
+	pla
+	and #$04 ; RTI restored I
+	beq *+5
+	jmp * ; trap: failed
+
+success:
+	jmp success ; trap: every test passed
+
+check_return:
+	tsx
+	lda $0101,x
+	cmp #<(return_point-1)
+	beq *+5
+	jmp * ; trap: failed
+	lda $0102,x
+	cmp #>(return_point-1)
+	beq *+5
+	jmp * ; trap: failed
+	rts
+
+brk_handler:
+	php
+	pla
+	and #$04
+	bne *+5
+	jmp * ; trap: failed
+	tsx
+	lda $0101,x ; the pushed P, Z from the lda #0 before the BRK
+	cmp #$32
+	beq *+5
+	jmp * ; trap: failed
+	lda $0102,x
+	cmp #<brk_return
+	beq *+5
+	jmp * ; trap: failed
+	lda $0103,x
+	cmp #>brk_return
+	beq *+5
+	jmp * ; trap: failed
+	inc tmp
+	rti
+
+; Branches that cross a page, forwards and backwards
+	.org $20F8
+page_branches:
+	lda #$01
+	bne page_forward ; from $20FC to the next page
+	jmp * ; trap: not taken
+page_back:
+	jmp page_branches_done
+	.org $2108
+page_forward:
+	lda #$00
+	beq page_back ; back to the previous page
+	jmp * ; trap: not taken
+
+; The targets of the JMP indirect test, on pages with the same low byte
+	.org $2200
+jmp_target:
+	jmp jmp_done
+	.org $2300
+jmp_wrong:
+	jmp * ; trap: the vector high byte came from $1400
+
+	.org $3000
+adc_table:
+	.byte $00, $00, $00, $00, $02
+	.byte $00, $00, $01, $01, $00
+	.byte $00, $01, $00, $01, $00
+	.byte $00, $01, $01, $02, $00
+	.byte $00, $0F, $00, $0F, $00
+	.byte $00, $0F, $01, $10, $00
+	.byte $00, $40, $00, $40, $00
+	.byte $00, $40, $01, $41, $00
+	.byte $00, $7F, $00, $7F, $00
+	.byte $00, $7F, $01, $80, $C0
+	.byte $00, $80, $00, $80, $80
+	.byte $00, $80, $01, $81, $80
+	.byte $00, $81, $00, $81, $80
+	.byte $00, $81, $01, $82, $80
+	.byte $00, $C0, $00, $C0, $80
+	.byte $00, $C0, $01, $C1, $80
+	.byte $00, $F0, $00, $F0, $80
+	.byte $00, $F0, $01, $F1, $80
+	.byte $00, $FF, $00, $FF, $80
+	.byte $00, $FF, $01, $00, $03
+	.byte $01, $00, $00, $01, $00
+	.byte $01, $00, $01, $02, $00
+	.byte $01, $01, $00, $02, $00
+	.byte $01, $01, $01, $03, $00
+	.byte $01, $0F, $00, $10, $00
+	.byte $01, $0F, $01, $11, $00
+	.byte $01, $40, $00, $41, $00
+	.byte $01, $40, $01, $42, $00
+	.byte $01, $7F, $00, $80, $C0
+	.byte $01, $7F, $01, $81, $C0
+	.byte $01, $80, $00, $81, $80
+	.byte $01, $80, $01, $82, $80
+	.byte $01, $81, $00, $82, $80
+	.byte $01, $81, $01, $83, $80
+	.byte $01, $C0, $00, $C1, $80
+	.byte $01, $C0, $01, $C2, $80
+	.byte $01, $F0, $00, $F1, $80
+	.byte $01, $F0, $01, $F2, $80
+	.byte $01, $FF, $00, $00, $03
+	.byte $01, $FF, $01, $01, $01
+	.byte $0F, $00, $00, $0F, $00
+	.byte $0F, $00, $01, $10, $00
+	.byte $0F, $01, $00, $10, $00
+	.byte $0F, $01, $01, $11, $00
+	.byte $0F, $0F, $00, $1E, $00
+	.byte $0F, $0F, $01, $1F, $00
+	.byte $0F, $40, $00, $4F, $00
+	.byte $0F, $40, $01, $50, $00
+	.byte $0F, $7F, $00, $8E, $C0
+	.byte $0F, $7F, $01, $8F, $C0
+	.byte $0F, $80, $00, $8F, $80
+	.byte $0F, $80, $01, $90, $80
+	.byte $0F, $81, $00, $90, $80
+	.byte $0F, $81, $01, $91, $80
+	.byte $0F, $C0, $00, $CF, $80
+	.byte $0F, $C0, $01, $D0, $80
+	.byte $0F, $F0, $00, $FF, $80
+	.byte $0F, $F0, $01, $00, $03
+	.byte $0F, $FF, $00, $0E, $01
+	.byte $0F, $FF, $01, $0F, $01
+	.byte $40, $00, $00, $40, $00
+	.byte $40, $00, $01, $41, $00
+	.byte $40, $01, $00, $41, $00
+	.byte $40, $01, $01, $42, $00
+	.byte $40, $0F, $00, $4F, $00
+	.byte $40, $0F, $01, $50, $00
+	.byte $40, $40, $00, $80, $C0
+	.byte $40, $40, $01, $81, $C0
+	.byte $40, $7F, $00, $BF, $C0
+	.byte $40, $7F, $01, $C0, $C0
+	.byte $40, $80, $00, $C0, $80
+	.byte $40, $80, $01, $C1, $80
+	.byte $40, $81, $00, $C1, $80
+	.byte $40, $81, $01, $C2, $80
+	.byte $40, $C0, $00, $00, $03
+	.byte $40, $C0, $01, $01, $01
+	.byte $40, $F0, $00, $30, $01
+	.byte $40, $F0, $01, $31, $01
+	.byte $40, $FF, $00, $3F, $01
+	.byte $40, $FF, $01, $40, $01
+	.byte $7F, $00, $00, $7F, $00
+	.byte $7F, $00, $01, $80, $C0
+	.byte $7F, $01, $00, $80, $C0
+	.byte $7F, $01, $01, $81, $C0
+	.byte $7F, $0F, $00, $8E, $C0
+	.byte $7F, $0F, $01, $8F, $C0
+	.byte $7F, $40, $00, $BF, $C0
+	.byte $7F, $40, $01, $C0, $C0
+	.byte $7F, $7F, $00, $FE, $C0
+	.byte $7F, $7F, $01, $FF, $C0
+	.byte $7F, $80, $00, $FF, $80
+	.byte $7F, $80, $01, $00, $03
+	.byte $7F, $81, $00, $00, $03
+	.byte $7F, $81, $01, $01, $01
+	.byte $7F, $C0, $00, $3F, $01
+	.byte $7F, $C0, $01, $40, $01
+	.byte $7F, $F0, $00, $6F, $01
+	.byte $7F, $F0, $01, $70, $01
+	.byte $7F, $FF, $00, $7E, $01
+	.byte $7F, $FF, $01, $7F, $01
+	.byte $80, $00, $00, $80, $80
+	.byte $80, $00, $01, $81, $80
+	.byte $80, $01, $00, $81, $80
+	.byte $80, $01, $01, $82, $80
+	.byte $80, $0F, $00, $8F, $80
+	.byte $80, $0F, $01, $90, $80
+	.byte $80, $40, $00, $C0, $80
+	.byte $80, $40, $01, $C1, $80
+	.byte $80, $7F, $00, $FF, $80
+	.byte $80, $7F, $01, $00, $03
+	.byte $80, $80, $00, $00, $43
+	.byte $80, $80, $01, $01, $41
+	.byte $80, $81, $00, $01, $41
+	.byte $80, $81, $01, $02, $41
+	.byte $80, $C0, $00, $40, $41
+	.byte $80, $C0, $01, $41, $41
+	.byte $80, $F0, $00, $70, $41
+	.byte $80, $F0, $01, $71, $41
+	.byte $80, $FF, $00, $7F, $41
+	.byte $80, $FF, $01, $80, $81
+	.byte $81, $00, $00, $81, $80
+	.byte $81, $00, $01, $82, $80
+	.byte $81, $01, $00, $82, $80
+	.byte $81, $01, $01, $83, $80
+	.byte $81, $0F, $00, $90, $80
+	.byte $81, $0F, $01, $91, $80
+	.byte $81, $40, $00, $C1, $80
+	.byte $81, $40, $01, $C2, $80
+	.byte $81, $7F, $00, $00, $03
+	.byte $81, $7F, $01, $01, $01
+	.byte $81, $80, $00, $01, $41
+	.byte $81, $80, $01, $02, $41
+	.byte $81, $81, $00, $02, $41
+	.byte $81, $81, $01, $03, $41
+	.byte $81, $C0, $00, $41, $41
+	.byte $81, $C0, $01, $42, $41
+	.byte $81, $F0, $00, $71, $41
+	.byte $81, $F0, $01, $72, $41
+	.byte $81, $FF, $00, $80, $81
+	.byte $81, $FF, $01, $81, $81
+	.byte $C0, $00, $00, $C0, $80
+	.byte $C0, $00, $01, $C1, $80
+	.byte $C0, $01, $00, $C1, $80
+	.byte $C0, $01, $01, $C2, $80
+	.byte $C0, $0F, $00, $CF, $80
+	.byte $C0, $0F, $01, $D0, $80
+	.byte $C0, $40, $00, $00, $03
+	.byte $C0, $40, $01, $01, $01
+	.byte $C0, $7F, $00, $3F, $01
+	.byte $C0, $7F, $01, $40, $01
+	.byte $C0, $80, $00, $40, $41
+	.byte $C0, $80, $01, $41, $41
+	.byte $C0, $81, $00, $41, $41
+	.byte $C0, $81, $01, $42, $41
+	.byte $C0, $C0, $00, $80, $81
+	.byte $C0, $C0, $01, $81, $81
+	.byte $C0, $F0, $00, $B0, $81
+	.byte $C0, $F0, $01, $B1, $81
+	.byte $C0, $FF, $00, $BF, $81
+	.byte $C0, $FF, $01, $C0, $81
+	.byte $F0, $00, $00, $F0, $80
+	.byte $F0, $00, $01, $F1, $80
+	.byte $F0, $01, $00, $F1, $80
+	.byte $F0, $01, $01, $F2, $80
+	.byte $F0, $0F, $00, $FF, $80
+	.byte $F0, $0F, $01, $00, $03
+	.byte $F0, $40, $00, $30, $01
+	.byte $F0, $40, $01, $31, $01
+	.byte $F0, $7F, $00, $6F, $01
+	.byte $F0, $7F, $01, $70, $01
+	.byte $F0, $80, $00, $70, $41
+	.byte $F0, $80, $01, $71, $41
+	.byte $F0, $81, $00, $71, $41
+	.byte $F0, $81, $01, $72, $41
+	.byte $F0, $C0, $00, $B0, $81
+	.byte $F0, $C0, $01, $B1, $81
+	.byte $F0, $F0, $00, $E0, $81
+	.byte $F0, $F0, $01, $E1, $81
+	.byte $F0, $FF, $00, $EF, $81
+	.byte $F0, $FF, $01, $F0, $81
+	.byte $FF, $00, $00, $FF, $80
+	.byte $FF, $00, $01, $00, $03
+	.byte $FF, $01, $00, $00, $03
+	.byte $FF, $01, $01, $01, $01
+	.byte $FF, $0F, $00, $0E, $01
+	.byte $FF, $0F, $01, $0F, $01
+	.byte $FF, $40, $00, $3F, $01
+	.byte $FF, $40, $01, $40, $01
+	.byte $FF, $7F, $00, $7E, $01
+	.byte $FF, $7F, $01, $7F, $01
+	.byte $FF, $80, $00, $7F, $41
+	.byte $FF, $80, $01, $80, $81
+	.byte $FF, $81, $00, $80, $81
+	.byte $FF, $81, $01, $81, $81
+	.byte $FF, $C0, $00, $BF, $81
+	.byte $FF, $C0, $01, $C0, $81
+	.byte $FF, $F0, $00, $EF, $81
+	.byte $FF, $F0, $01, $F0, $81
+	.byte $FF, $FF, $00, $FE, $81
+	.byte $FF, $FF, $01, $FF, $81
+adc_end:
+sbc_table:
+	.byte $00, $00, $00, $FF, $80
+	.byte $00, $00, $01, $00, $03
+	.byte $00, $01, $00, $FE, $80
+	.byte $00, $01, $01, $FF, $80
+	.byte $00, $0F, $00, $F0, $80
+	.byte $00, $0F, $01, $F1, $80
+	.byte $00, $40, $00, $BF, $80
+	.byte $00, $40, $01, $C0, $80
+	.byte $00, $7F, $00, $80, $80
+	.byte $00, $7F, $01, $81, $80
+	.byte $00, $80, $00, $7F, $00
+	.byte $00, $80, $01, $80, $C0
+	.byte $00, $81, $00, $7E, $00
+	.byte $00, $81, $01, $7F, $00
+	.byte $00, $C0, $00, $3F, $00
+	.byte $00, $C0, $01, $40, $00
+	.byte $00, $F0, $00, $0F, $00
+	.byte $00, $F0, $01, $10, $00
+	.byte $00, $FF, $00, $00, $02
+	.byte $00, $FF, $01, $01, $00
+	.byte $01, $00, $00, $00, $03
+	.byte $01, $00, $01, $01, $01
+	.byte $01, $01, $00, $FF, $80
+	.byte $01, $01, $01, $00, $03
+	.byte $01, $0F, $00, $F1, $80
+	.byte $01, $0F, $01, $F2, $80
+	.byte $01, $40, $00, $C0, $80
+	.byte $01, $40, $01, $C1, $80
+	.byte $01, $7F, $00, $81, $80
+	.byte $01, $7F, $01, $82, $80
+	.byte $01, $80, $00, $80, $C0
+	.byte $01, $80, $01, $81, $C0
+	.byte $01, $81, $00, $7F, $00
+	.byte $01, $81, $01, $80, $C0
+	.byte $01, $C0, $00, $40, $00
+	.byte $01, $C0, $01, $41, $00
+	.byte $01, $F0, $00, $10, $00
+	.byte $01, $F0, $01, $11, $00
+	.byte $01, $FF, $00, $01, $00
+	.byte $01, $FF, $01, $02, $00
+	.byte $0F, $00, $00, $0E, $01
+	.byte $0F, $00, $01, $0F, $01
+	.byte $0F, $01, $00, $0D, $01
+	.byte $0F, $01, $01, $0E, $01
+	.byte $0F, $0F, $00, $FF, $80
+	.byte $0F, $0F, $01, $00, $03
+	.byte $0F, $40, $00, $CE, $80
+	.byte $0F, $40, $01, $CF, $80
+	.byte $0F, $7F, $00, $8F, $80
+	.byte $0F, $7F, $01, $90, $80
+	.byte $0F, $80, $00, $8E, $C0
+	.byte $0F, $80, $01, $8F, $C0
+	.byte $0F, $81, $00, $8D, $C0
+	.byte $0F, $81, $01, $8E, $C0
+	.byte $0F, $C0, $00, $4E, $00
+	.byte $0F, $C0, $01, $4F, $00
+	.byte $0F, $F0, $00, $1E, $00
+	.byte $0F, $F0, $01, $1F, $00
+	.byte $0F, $FF, $00, $0F, $00
+	.byte $0F, $FF, $01, $10, $00
+	.byte $40, $00, $00, $3F, $01
+	.byte $40, $00, $01, $40, $01
+	.byte $40, $01, $00, $3E, $01
+	.byte $40, $01, $01, $3F, $01
+	.byte $40, $0F, $00, $30, $01
+	.byte $40, $0F, $01, $31, $01
+	.byte $40, $40, $00, $FF, $80
+	.byte $40, $40, $01, $00, $03
+	.byte $40, $7F, $00, $C0, $80
+	.byte $40, $7F, $01, $C1, $80
+	.byte $40, $80, $00, $BF, $C0
+	.byte $40, $80, $01, $C0, $C0
+	.byte $40, $81, $00, $BE, $C0
+	.byte $40, $81, $01, $BF, $C0
+	.byte $40, $C0, $00, $7F, $00
+	.byte $40, $C0, $01, $80, $C0
+	.byte $40, $F0, $00, $4F, $00
+	.byte $40, $F0, $01, $50, $00
+	.byte $40, $FF, $00, $40, $00
+	.byte $40, $FF, $01, $41, $00
+	.byte $7F, $00, $00, $7E, $01
+	.byte $7F, $00, $01, $7F, $01
+	.byte $7F, $01, $00, $7D, $01
+	.byte $7F, $01, $01, $7E, $01
+	.byte $7F, $0F, $00, $6F, $01
+	.byte $7F, $0F, $01, $70, $01
+	.byte $7F, $40, $00, $3E, $01
+	.byte $7F, $40, $01, $3F, $01
+	.byte $7F, $7F, $00, $FF, $80
+	.byte $7F, $7F, $01, $00, $03
+	.byte $7F, $80, $00, $FE, $C0
+	.byte $7F, $80, $01, $FF, $C0
+	.byte $7F, $81, $00, $FD, $C0
+	.byte $7F, $81, $01, $FE, $C0
+	.byte $7F, $C0, $00, $BE, $C0
+	.byte $7F, $C0, $01, $BF, $C0
+	.byte $7F, $F0, $00, $8E, $C0
+	.byte $7F, $F0, $01, $8F, $C0
+	.byte $7F, $FF, $00, $7F, $00
+	.byte $7F, $FF, $01, $80, $C0
+	.byte $80, $00, $00, $7F, $41
+	.byte $80, $00, $01, $80, $81
+	.byte $80, $01, $00, $7E, $41
+	.byte $80, $01, $01, $7F, $41
+	.byte $80, $0F, $00, $70, $41
+	.byte $80, $0F, $01, $71, $41
+	.byte $80, $40, $00, $3F, $41
+	.byte $80, $40, $01, $40, $41
+	.byte $80, $7F, $00, $00, $43
+	.byte $80, $7F, $01, $01, $41
+	.byte $80, $80, $00, $FF, $80
+	.byte $80, $80, $01, $00, $03
+	.byte $80, $81, $00, $FE, $80
+	.byte $80, $81, $01, $FF, $80
+	.byte $80, $C0, $00, $BF, $80
+	.byte $80, $C0, $01, $C0, $80
+	.byte $80, $F0, $00, $8F, $80
+	.byte $80, $F0, $01, $90, $80
+	.byte $80, $FF, $00, $80, $80
+	.byte $80, $FF, $01, $81, $80
+	.byte $81, $00, $00, $80, $81
+	.byte $81, $00, $01, $81, $81
+	.byte $81, $01, $00, $7F, $41
+	.byte $81, $01, $01, $80, $81
+	.byte $81, $0F, $00, $71, $41
+	.byte $81, $0F, $01, $72, $41
+	.byte $81, $40, $00, $40, $41
+	.byte $81, $40, $01, $41, $41
+	.byte $81, $7F, $00, $01, $41
+	.byte $81, $7F, $01, $02, $41
+	.byte $81, $80, $00, $00, $03
+	.byte $81, $80, $01, $01, $01
+	.byte $81, $81, $00, $FF, $80
+	.byte $81, $81, $01, $00, $03
+	.byte $81, $C0, $00, $C0, $80
+	.byte $81, $C0, $01, $C1, $80
+	.byte $81, $F0, $00, $90, $80
+	.byte $81, $F0, $01, $91, $80
+	.byte $81, $FF, $00, $81, $80
+	.byte $81, $FF, $01, $82, $80
+	.byte $C0, $00, $00, $BF, $81
+	.byte $C0, $00, $01, $C0, $81
+	.byte $C0, $01, $00, $BE, $81
+	.byte $C0, $01, $01, $BF, $81
+	.byte $C0, $0F, $00, $B0, $81
+	.byte $C0, $0F, $01, $B1, $81
+	.byte $C0, $40, $00, $7F, $41
+	.byte $C0, $40, $01, $80, $81
+	.byte $C0, $7F, $00, $40, $41
+	.byte $C0, $7F, $01, $41, $41
+	.byte $C0, $80, $00, $3F, $01
+	.byte $C0, $80, $01, $40, $01
+	.byte $C0, $81, $00, $3E, $01
+	.byte $C0, $81, $01, $3F, $01
+	.byte $C0, $C0, $00, $FF, $80
+	.byte $C0, $C0, $01, $00, $03
+	.byte $C0, $F0, $00, $CF, $80
+	.byte $C0, $F0, $01, $D0, $80
+	.byte $C0, $FF, $00, $C0, $80
+	.byte $C0, $FF, $01, $C1, $80
+	.byte $F0, $00, $00, $EF, $81
+	.byte $F0, $00, $01, $F0, $81
+	.byte $F0, $01, $00, $EE, $81
+	.byte $F0, $01, $01, $EF, $81
+	.byte $F0, $0F, $00, $E0, $81
+	.byte $F0, $0F, $01, $E1, $81
+	.byte $F0, $40, $00, $AF, $81
+	.byte $F0, $40, $01, $B0, $81
+	.byte $F0, $7F, $00, $70, $41
+	.byte $F0, $7F, $01, $71, $41
+	.byte $F0, $80, $00, $6F, $01
+	.byte $F0, $80, $01, $70, $01
+	.byte $F0, $81, $00, $6E, $01
+	.byte $F0, $81, $01, $6F, $01
+	.byte $F0, $C0, $00, $2F, $01
+	.byte $F0, $C0, $01, $30, $01
+	.byte $F0, $F0, $00, $FF, $80
+	.byte $F0, $F0, $01, $00, $03
+	.byte $F0, $FF, $00, $F0, $80
+	.byte $F0, $FF, $01, $F1, $80
+	.byte $FF, $00, $00, $FE, $81
+	.byte $FF, $00, $01, $FF, $81
+	.byte $FF, $01, $00, $FD, $81
+	.byte $FF, $01, $01, $FE, $81
+	.byte $FF, $0F, $00, $EF, $81
+	.byte $FF, $0F, $01, $F0, $81
+	.byte $FF, $40, $00, $BE, $81
+	.byte $FF, $40, $01, $BF, $81
+	.byte $FF, $7F, $00, $7F, $41
+	.byte $FF, $7F, $01, $80, $81
+	.byte $FF, $80, $00, $7E, $01
+	.byte $FF, $80, $01, $7F, $01
+	.byte $FF, $81, $00, $7D, $01
+	.byte $FF, $81, $01, $7E, $01
+	.byte $FF, $C0, $00, $3E, $01
+	.byte $FF, $C0, $01, $3F, $01
+	.byte $FF, $F0, $00, $0E, $01
+	.byte $FF, $F0, $01, $0F, $01
+	.byte $FF, $FF, $00, $FF, $80
+	.byte $FF, $FF, $01, $00, $03
+sbc_end:
+cmp_table:
+	.byte $00, $00, $00, $00, $03
+	.byte $00, $00, $01, $00, $03
+	.byte $00, $01, $00, $00, $80
+	.byte $00, $01, $01, $00, $80
+	.byte $00, $0F, $00, $00, $80
+	.byte $00, $0F, $01, $00, $80
+	.byte $00, $40, $00, $00, $80
+	.byte $00, $40, $01, $00, $80
+	.byte $00, $7F, $00, $00, $80
+	.byte $00, $7F, $01, $00, $80
+	.byte $00, $80, $00, $00, $80
+	.byte $00, $80, $01, $00, $80
+	.byte $00, $81, $00, $00, $00
+	.byte $00, $81, $01, $00, $00
+	.byte $00, $C0, $00, $00, $00
+	.byte $00, $C0, $01, $00, $00
+	.byte $00, $F0, $00, $00, $00
+	.byte $00, $F0, $01, $00, $00
+	.byte $00, $FF, $00, $00, $00
+	.byte $00, $FF, $01, $00, $00
+	.byte $01, $00, $00, $01, $01
+	.byte $01, $00, $01, $01, $01
+	.byte $01, $01, $00, $01, $03
+	.byte $01, $01, $01, $01, $03
+	.byte $01, $0F, $00, $01, $80
+	.byte $01, $0F, $01, $01, $80
+	.byte $01, $40, $00, $01, $80
+	.byte $01, $40, $01, $01, $80
+	.byte $01, $7F, $00, $01, $80
+	.byte $01, $7F, $01, $01, $80
+	.byte $01, $80, $00, $01, $80
+	.byte $01, $80, $01, $01, $80
+	.byte $01, $81, $00, $01, $80
+	.byte $01, $81, $01, $01, $80
+	.byte $01, $C0, $00, $01, $00
+	.byte $01, $C0, $01, $01, $00
+	.byte $01, $F0, $00, $01, $00
+	.byte $01, $F0, $01, $01, $00
+	.byte $01, $FF, $00, $01, $00
+	.byte $01, $FF, $01, $01, $00
+	.byte $0F, $00, $00, $0F, $01
+	.byte $0F, $00, $01, $0F, $01
+	.byte $0F, $01, $00, $0F, $01
+	.byte $0F, $01, $01, $0F, $01
+	.byte $0F, $0F, $00, $0F, $03
+	.byte $0F, $0F, $01, $0F, $03
+	.byte $0F, $40, $00, $0F, $80
+	.byte $0F, $40, $01, $0F, $80
+	.byte $0F, $7F, $00, $0F, $80
+	.byte $0F, $7F, $01, $0F, $80
+	.byte $0F, $80, $00, $0F, $80
+	.byte $0F, $80, $01, $0F, $80
+	.byte $0F, $81, $00, $0F, $80
+	.byte $0F, $81, $01, $0F, $80
+	.byte $0F, $C0, $00, $0F, $00
+	.byte $0F, $C0, $01, $0F, $00
+	.byte $0F, $F0, $00, $0F, $00
+	.byte $0F, $F0, $01, $0F, $00
+	.byte $0F, $FF, $00, $0F, $00
+	.byte $0F, $FF, $01, $0F, $00
+	.byte $40, $00, $00, $40, $01
+	.byte $40, $00, $01, $40, $01
+	.byte $40, $01, $00, $40, $01
+	.byte $40, $01, $01, $40, $01
+	.byte $40, $0F, $00, $40, $01
+	.byte $40, $0F, $01, $40, $01
+	.byte $40, $40, $00, $40, $03
+	.byte $40, $40, $01, $40, $03
+	.byte $40, $7F, $00, $40, $80
+	.byte $40, $7F, $01, $40, $80
+	.byte $40, $80, $00, $40, $80
+	.byte $40, $80, $01, $40, $80
+	.byte $40, $81, $00, $40, $80
+	.byte $40, $81, $01, $40, $80
+	.byte $40, $C0, $00, $40, $80
+	.byte $40, $C0, $01, $40, $80
+	.byte $40, $F0, $00, $40, $00
+	.byte $40, $F0, $01, $40, $00
+	.byte $40, $FF, $00, $40, $00
+	.byte $40, $FF, $01, $40, $00
+	.byte $7F, $00, $00, $7F, $01
+	.byte $7F, $00, $01, $7F, $01
+	.byte $7F, $01, $00, $7F, $01
+	.byte $7F, $01, $01, $7F, $01
+	.byte $7F, $0F, $00, $7F, $01
+	.byte $7F, $0F, $01, $7F, $01
+	.byte $7F, $40, $00, $7F, $01
+	.byte $7F, $40, $01, $7F, $01
+	.byte $7F, $7F, $00, $7F, $03
+	.byte $7F, $7F, $01, $7F, $03
+	.byte $7F, $80, $00, $7F, $80
+	.byte $7F, $80, $01, $7F, $80
+	.byte $7F, $81, $00, $7F, $80
+	.byte $7F, $81, $01, $7F, $80
+	.byte $7F, $C0, $00, $7F, $80
+	.byte $7F, $C0, $01, $7F, $80
+	.byte $7F, $F0, $00, $7F, $80
+	.byte $7F, $F0, $01, $7F, $80
+	.byte $7F, $FF, $00, $7F, $80
+	.byte $7F, $FF, $01, $7F, $80
+	.byte $80, $00, $00, $80, $81
+	.byte $80, $00, $01, $80, $81
+	.byte $80, $01, $00, $80, $01
+	.byte $80, $01, $01, $80, $01
+	.byte $80, $0F, $00, $80, $01
+	.byte $80, $0F, $01, $80, $01
+	.byte $80, $40, $00, $80, $01
+	.byte $80, $40, $01, $80, $01
+	.byte $80, $7F, $00, $80, $01
+	.byte $80, $7F, $01, $80, $01
+	.byte $80, $80, $00, $80, $03
+	.byte $80, $80, $01, $80, $03
+	.byte $80, $81, $00, $80, $80
+	.byte $80, $81, $01, $80, $80
+	.byte $80, $C0, $00, $80, $80
+	.byte $80, $C0, $01, $80, $80
+	.byte $80, $F0, $00, $80, $80
+	.byte $80, $F0, $01, $80, $80
+	.byte $80, $FF, $00, $80, $80
+	.byte $80, $FF, $01, $80, $80
+	.byte $81, $00, $00, $81, $81
+	.byte $81, $00, $01, $81, $81
+	.byte $81, $01, $00, $81, $81
+	.byte $81, $01, $01, $81, $81
+	.byte $81, $0F, $00, $81, $01
+	.byte $81, $0F, $01, $81, $01
+	.byte $81, $40, $00, $81, $01
+	.byte $81, $40, $01, $81, $01
+	.byte $81, $7F, $00, $81, $01
+	.byte $81, $7F, $01, $81, $01
+	.byte $81, $80, $00, $81, $01
+	.byte $81, $80, $01, $81, $01
+	.byte $81, $81, $00, $81, $03
+	.byte $81, $81, $01, $81, $03
+	.byte $81, $C0, $00, $81, $80
+	.byte $81, $C0, $01, $81, $80
+	.byte $81, $F0, $00, $81, $80
+	.byte $81, $F0, $01, $81, $80
+	.byte $81, $FF, $00, $81, $80
+	.byte $81, $FF, $01, $81, $80
+	.byte $C0, $00, $00, $C0, $81
+	.byte $C0, $00, $01, $C0, $81
+	.byte $C0, $01, $00, $C0, $81
+	.byte $C0, $01, $01, $C0, $81
+	.byte $C0, $0F, $00, $C0, $81
+	.byte $C0, $0F, $01, $C0, $81
+	.byte $C0, $40, $00, $C0, $81
+	.byte $C0, $40, $01, $C0, $81
+	.byte $C0, $7F, $00, $C0, $01
+	.byte $C0, $7F, $01, $C0, $01
+	.byte $C0, $80, $00, $C0, $01
+	.byte $C0, $80, $01, $C0, $01
+	.byte $C0, $81, $00, $C0, $01
+	.byte $C0, $81, $01, $C0, $01
+	.byte $C0, $C0, $00, $C0, $03
+	.byte $C0, $C0, $01, $C0, $03
+	.byte $C0, $F0, $00, $C0, $80
+	.byte $C0, $F0, $01, $C0, $80
+	.byte $C0, $FF, $00, $C0, $80
+	.byte $C0, $FF, $01, $C0, $80
+	.byte $F0, $00, $00, $F0, $81
+	.byte $F0, $00, $01, $F0, $81
+	.byte $F0, $01, $00, $F0, $81
+	.byte $F0, $01, $01, $F0, $81
+	.byte $F0, $0F, $00, $F0, $81
+	.byte $F0, $0F, $01, $F0, $81
+	.byte $F0, $40, $00, $F0, $81
+	.byte $F0, $40, $01, $F0, $81
+	.byte $F0, $7F, $00, $F0, $01
+	.byte $F0, $7F, $01, $F0, $01
+	.byte $F0, $80, $00, $F0, $01
+	.byte $F0, $80, $01, $F0, $01
+	.byte $F0, $81, $00, $F0, $01
+	.byte $F0, $81, $01, $F0, $01
+	.byte $F0, $C0, $00, $F0, $01
+	.byte $F0, $C0, $01, $F0, $01
+	.byte $F0, $F0, $00, $F0, $03
+	.byte $F0, $F0, $01, $F0, $03
+	.byte $F0, $FF, $00, $F0, $80
+	.byte $F0, $FF, $01, $F0, $80
+	.byte $FF, $00, $00, $FF, $81
+	.byte $FF, $00, $01, $FF, $81
+	.byte $FF, $01, $00, $FF, $81
+	.byte $FF, $01, $01, $FF, $81
+	.byte $FF, $0F, $00, $FF, $81
+	.byte $FF, $0F, $01, $FF, $81
+	.byte $FF, $40, $00, $FF, $81
+	.byte $FF, $40, $01, $FF, $81
+	.byte $FF, $7F, $00, $FF, $81
+	.byte $FF, $7F, $01, $FF, $81
+	.byte $FF, $80, $00, $FF, $01
+	.byte $FF, $80, $01, $FF, $01
+	.byte $FF, $81, $00, $FF, $01
+	.byte $FF, $81, $01, $FF, $01
+	.byte $FF, $C0, $00, $FF, $01
+	.byte $FF, $C0, $01, $FF, $01
+	.byte $FF, $F0, $00, $FF, $01
+	.byte $FF, $F0, $01, $FF, $01
+	.byte $FF, $FF, $00, $FF, $03
+	.byte $FF, $FF, $01, $FF, $03
+cmp_end:
+and_table:
+	.byte $00, $00, $00, $00, $02
+	.byte $00, $00, $01, $00, $03
+	.byte $00, $01, $00, $00, $02
+	.byte $00, $01, $01, $00, $03
+	.byte $00, $0F, $00, $00, $02
+	.byte $00, $0F, $01, $00, $03
+	.byte $00, $40, $00, $00, $02
+	.byte $00, $40, $01, $00, $03
+	.byte $00, $7F, $00, $00, $02
+	.byte $00, $7F, $01, $00, $03
+	.byte $00, $80, $00, $00, $02
+	.byte $00, $80, $01, $00, $03
+	.byte $00, $81, $00, $00, $02
+	.byte $00, $81, $01, $00, $03
+	.byte $00, $C0, $00, $00, $02
+	.byte $00, $C0, $01, $00, $03
+	.byte $00, $F0, $00, $00, $02
+	.byte $00, $F0, $01, $00, $03
+	.byte $00, $FF, $00, $00, $02
+	.byte $00, $FF, $01, $00, $03
+	.byte $01, $00, $00, $00, $02
+	.byte $01, $00, $01, $00, $03
+	.byte $01, $01, $00, $01, $00
+	.byte $01, $01, $01, $01, $01
+	.byte $01, $0F, $00, $01, $00
+	.byte $01, $0F, $01, $01, $01
+	.byte $01, $40, $00, $00, $02
+	.byte $01, $40, $01, $00, $03
+	.byte $01, $7F, $00, $01, $00
+	.byte $01, $7F, $01, $01, $01
+	.byte $01, $80, $00, $00, $02
+	.byte $01, $80, $01, $00, $03
+	.byte $01, $81, $00, $01, $00
+	.byte $01, $81, $01, $01, $01
+	.byte $01, $C0, $00, $00, $02
+	.byte $01, $C0, $01, $00, $03
+	.byte $01, $F0, $00, $00, $02
+	.byte $01, $F0, $01, $00, $03
+	.byte $01, $FF, $00, $01, $00
+	.byte $01, $FF, $01, $01, $01
+	.byte $0F, $00, $00, $00, $02
+	.byte $0F, $00, $01, $00, $03
+	.byte $0F, $01, $00, $01, $00
+	.byte $0F, $01, $01, $01, $01
+	.byte $0F, $0F, $00, $0F, $00
+	.byte $0F, $0F, $01, $0F, $01
+	.byte $0F, $40, $00, $00, $02
+	.byte $0F, $40, $01, $00, $03
+	.byte $0F, $7F, $00, $0F, $00
+	.byte $0F, $7F, $01, $0F, $01
+	.byte $0F, $80, $00, $00, $02
+	.byte $0F, $80, $01, $00, $03
+	.byte $0F, $81, $00, $01, $00
+	.byte $0F, $81, $01, $01, $01
+	.byte $0F, $C0, $00, $00, $02
+	.byte $0F, $C0, $01, $00, $03
+	.byte $0F, $F0, $00, $00, $02
+	.byte $0F, $F0, $01, $00, $03
+	.byte $0F, $FF, $00, $0F, $00
+	.byte $0F, $FF, $01, $0F, $01
+	.byte $40, $00, $00, $00, $02
+	.byte $40, $00, $01, $00, $03
+	.byte $40, $01, $00, $00, $02
+	.byte $40, $01, $01, $00, $03
+	.byte $40, $0F, $00, $00, $02
+	.byte $40, $0F, $01, $00, $03
+	.byte $40, $40, $00, $40, $00
+	.byte $40, $40, $01, $40, $01
+	.byte $40, $7F, $00, $40, $00
+	.byte $40, $7F, $01, $40, $01
+	.byte $40, $80, $00, $00, $02
+	.byte $40, $80, $01, $00, $03
+	.byte $40, $81, $00, $00, $02
+	.byte $40, $81, $01, $00, $03
+	.byte $40, $C0, $00, $40, $00
+	.byte $40, $C0, $01, $40, $01
+	.byte $40, $F0, $00, $40, $00
+	.byte $40, $F0, $01, $40, $01
+	.byte $40, $FF, $00, $40, $00
+	.byte $40, $FF, $01, $40, $01
+	.byte $7F, $00, $00, $00, $02
+	.byte $7F, $00, $01, $00, $03
+	.byte $7F, $01, $00, $01, $00
+	.byte $7F, $01, $01, $01, $01
+	.byte $7F, $0F, $00, $0F, $00
+	.byte $7F, $0F, $01, $0F, $01
+	.byte $7F, $40, $00, $40, $00
+	.byte $7F, $40, $01, $40, $01
+	.byte $7F, $7F, $00, $7F, $00
+	.byte $7F, $7F, $01, $7F, $01
+	.byte $7F, $80, $00, $00, $02
+	.byte $7F, $80, $01, $00, $03
+	.byte $7F, $81, $00, $01, $00
+	.byte $7F, $81, $01, $01, $01
+	.byte $7F, $C0, $00, $40, $00
+	.byte $7F, $C0, $01, $40, $01
+	.byte $7F, $F0, $00, $70, $00
+	.byte $7F, $F0, $01, $70, $01
+	.byte $7F, $FF, $00, $7F, $00
+	.byte $7F, $FF, $01, $7F, $01
+	.byte $80, $00, $00, $00, $02
+	.byte $80, $00, $01, $00, $03
+	.byte $80, $01, $00, $00, $02
+	.byte $80, $01, $01, $00, $03
+	.byte $80, $0F, $00, $00, $02
+	.byte $80, $0F, $01, $00, $03
+	.byte $80, $40, $00, $00, $02
+	.byte $80, $40, $01, $00, $03
+	.byte $80, $7F, $00, $00, $02
+	.byte $80, $7F, $01, $00, $03
+	.byte $80, $80, $00, $80, $80
+	.byte $80, $80, $01, $80, $81
+	.byte $80, $81, $00, $80, $80
+	.byte $80, $81, $01, $80, $81
+	.byte $80, $C0, $00, $80, $80
+	.byte $80, $C0, $01, $80, $81
+	.byte $80, $F0, $00, $80, $80
+	.byte $80, $F0, $01, $80, $81
+	.byte $80, $FF, $00, $80, $80
+	.byte $80, $FF, $01, $80, $81
+	.byte $81, $00, $00, $00, $02
+	.byte $81, $00, $01, $00, $03
+	.byte $81, $01, $00, $01, $00
+	.byte $81, $01, $01, $01, $01
+	.byte $81, $0F, $00, $01, $00
+	.byte $81, $0F, $01, $01, $01
+	.byte $81, $40, $00, $00, $02
+	.byte $81, $40, $01, $00, $03
+	.byte $81, $7F, $00, $01, $00
+	.byte $81, $7F, $01, $01, $01
+	.byte $81, $80, $00, $80, $80
+	.byte $81, $80, $01, $80, $81
+	.byte $81, $81, $00, $81, $80
+	.byte $81, $81, $01, $81, $81
+	.byte $81, $C0, $00, $80, $80
+	.byte $81, $C0, $01, $80, $81
+	.byte $81, $F0, $00, $80, $80
+	.byte $81, $F0, $01, $80, $81
+	.byte $81, $FF, $00, $81, $80
+	.byte $81, $FF, $01, $81, $81
+	.byte $C0, $00, $00, $00, $02
+	.byte $C0, $00, $01, $00, $03
+	.byte $C0, $01, $00, $00, $02
+	.byte $C0, $01, $01, $00, $03
+	.byte $C0, $0F, $00, $00, $02
+	.byte $C0, $0F, $01, $00, $03
+	.byte $C0, $40, $00, $40, $00
+	.byte $C0, $40, $01, $40, $01
+	.byte $C0, $7F, $00, $40, $00
+	.byte $C0, $7F, $01, $40, $01
+	.byte $C0, $80, $00, $80, $80
+	.byte $C0, $80, $01, $80, $81
+	.byte $C0, $81, $00, $80, $80
+	.byte $C0, $81, $01, $80, $81
+	.byte $C0, $C0, $00, $C0, $80
+	.byte $C0, $C0, $01, $C0, $81
+	.byte $C0, $F0, $00, $C0, $80
+	.byte $C0, $F0, $01, $C0, $81
+	.byte $C0, $FF, $00, $C0, $80
+	.byte $C0, $FF, $01, $C0, $81
+	.byte $F0, $00, $00, $00, $02
+	.byte $F0, $00, $01, $00, $03
+	.byte $F0, $01, $00, $00, $02
+	.byte $F0, $01, $01, $00, $03
+	.byte $F0, $0F, $00, $00, $02
+	.byte $F0, $0F, $01, $00, $03
+	.byte $F0, $40, $00, $40, $00
+	.byte $F0, $40, $01, $40, $01
+	.byte $F0, $7F, $00, $70, $00
+	.byte $F0, $7F, $01, $70, $01
+	.byte $F0, $80, $00, $80, $80
+	.byte $F0, $80, $01, $80, $81
+	.byte $F0, $81, $00, $80, $80
+	.byte $F0, $81, $01, $80, $81
+	.byte $F0, $C0, $00, $C0, $80
+	.byte $F0, $C0, $01, $C0, $81
+	.byte $F0, $F0, $00, $F0, $80
+	.byte $F0, $F0, $01, $F0, $81
+	.byte $F0, $FF, $00, $F0, $80
+	.byte $F0, $FF, $01, $F0, $81
+	.byte $FF, $00, $00, $00, $02
+	.byte $FF, $00, $01, $00, $03
+	.byte $FF, $01, $00, $01, $00
+	.byte $FF, $01, $01, $01, $01
+	.byte $FF, $0F, $00, $0F, $00
+	.byte $FF, $0F, $01, $0F, $01
+	.byte $FF, $40, $00, $40, $00
+	.byte $FF, $40, $01, $40, $01
+	.byte $FF, $7F, $00, $7F, $00
+	.byte $FF, $7F, $01, $7F, $01
+	.byte $FF, $80, $00, $80, $80
+	.byte $FF, $80, $01, $80, $81
+	.byte $FF, $81, $00, $81, $80
+	.byte $FF, $81, $01, $81, $81
+	.byte $FF, $C0, $00, $C0, $80
+	.byte $FF, $C0, $01, $C0, $81
+	.byte $FF, $F0, $00, $F0, $80
+	.byte $FF, $F0, $01, $F0, $81
+	.byte $FF, $FF, $00, $FF, $80
+	.byte $FF, $FF, $01, $FF, $81
+and_end:
+ora_table:
+	.byte $00, $00, $00, $00, $02
+	.byte $00, $00, $01, $00, $03
+	.byte $00, $01, $00, $01, $00
+	.byte $00, $01, $01, $01, $01
+	.byte $00, $0F, $00, $0F, $00
+	.byte $00, $0F, $01, $0F, $01
+	.byte $00, $40, $00, $40, $00
+	.byte $00, $40, $01, $40, $01
+	.byte $00, $7F, $00, $7F, $00
+	.byte $00, $7F, $01, $7F, $01
+	.byte $00, $80, $00, $80, $80
+	.byte $00, $80, $01, $80, $81
+	.byte $00, $81, $00, $81, $80
+	.byte $00, $81, $01, $81, $81
+	.byte $00, $C0, $00, $C0, $80
+	.byte $00, $C0, $01, $C0, $81
+	.byte $00, $F0, $00, $F0, $80
+	.byte $00, $F0, $01, $F0, $81
+	.byte $00, $FF, $00, $FF, $80
+	.byte $00, $FF, $01, $FF, $81
+	.byte $01, $00, $00, $01, $00
+	.byte $01, $00, $01, $01, $01
+	.byte $01, $01, $00, $01, $00
+	.byte $01, $01, $01, $01, $01
+	.byte $01, $0F, $00, $0F, $00
+	.byte $01, $0F, $01, $0F, $01
+	.byte $01, $40, $00, $41, $00
+	.byte $01, $40, $01, $41, $01
+	.byte $01, $7F, $00, $7F, $00
+	.byte $01, $7F, $01, $7F, $01
+	.byte $01, $80, $00, $81, $80
+	.byte $01, $80, $01, $81, $81
+	.byte $01, $81, $00, $81, $80
+	.byte $01, $81, $01, $81, $81
+	.byte $01, $C0, $00, $C1, $80
+	.byte $01, $C0, $01, $C1, $81
+	.byte $01, $F0, $00, $F1, $80
+	.byte $01, $F0, $01, $F1, $81
+	.byte $01, $FF, $00, $FF, $80
+	.byte $01, $FF, $01, $FF, $81
+	.byte $0F, $00, $00, $0F, $00
+	.byte $0F, $00, $01, $0F, $01
+	.byte $0F, $01, $00, $0F, $00
+	.byte $0F, $01, $01, $0F, $01
+	.byte $0F, $0F, $00, $0F, $00
+	.byte $0F, $0F, $01, $0F, $01
+	.byte $0F, $40, $00, $4F, $00
+	.byte $0F, $40, $01, $4F, $01
+	.byte $0F, $7F, $00, $7F, $00
+	.byte $0F, $7F, $01, $7F, $01
+	.byte $0F, $80, $00, $8F, $80
+	.byte $0F, $80, $01, $8F, $81
+	.byte $0F, $81, $00, $8F, $80
+	.byte $0F, $81, $01, $8F, $81
+	.byte $0F, $C0, $00, $CF, $80
+	.byte $0F, $C0, $01, $CF, $81
+	.byte $0F, $F0, $00, $FF, $80
+	.byte $0F, $F0, $01, $FF, $81
+	.byte $0F, $FF, $00, $FF, $80
+	.byte $0F, $FF, $01, $FF, $81
+	.byte $40, $00, $00, $40, $00
+	.byte $40, $00, $01, $40, $01
+	.byte $40, $01, $00, $41, $00
+	.byte $40, $01, $01, $41, $01
+	.byte $40, $0F, $00, $4F, $00
+	.byte $40, $0F, $01, $4F, $01
+	.byte $40, $40, $00, $40, $00
+	.byte $40, $40, $01, $40, $01
+	.byte $40, $7F, $00, $7F, $00
+	.byte $40, $7F, $01, $7F, $01
+	.byte $40, $80, $00, $C0, $80
+	.byte $40, $80, $01, $C0, $81
+	.byte $40, $81, $00, $C1, $80
+	.byte $40, $81, $01, $C1, $81
+	.byte $40, $C0, $00, $C0, $80
+	.byte $40, $C0, $01, $C0, $81
+	.byte $40, $F0, $00, $F0, $80
+	.byte $40, $F0, $01, $F0, $81
+	.byte $40, $FF, $00, $FF, $80
+	.byte $40, $FF, $01, $FF, $81
+	.byte $7F, $00, $00, $7F, $00
+	.byte $7F, $00, $01, $7F, $01
+	.byte $7F, $01, $00, $7F, $00
+	.byte $7F, $01, $01, $7F, $01
+	.byte $7F, $0F, $00, $7F, $00
+	.byte $7F, $0F, $01, $7F, $01
+	.byte $7F, $40, $00, $7F, $00
+	.byte $7F, $40, $01, $7F, $01
+	.byte $7F, $7F, $00, $7F, $00
+	.byte $7F, $7F, $01, $7F, $01
+	.byte $7F, $80, $00, $FF, $80
+	.byte $7F, $80, $01, $FF, $81
+	.byte $7F, $81, $00, $FF, $80
+	.byte $7F, $81, $01, $FF, $81
+	.byte $7F, $C0, $00, $FF, $80
+	.byte $7F, $C0, $01, $FF, $81
+	.byte $7F, $F0, $00, $FF, $80
+	.byte $7F, $F0, $01, $FF, $81
+	.byte $7F, $FF, $00, $FF, $80
+	.byte $7F, $FF, $01, $FF, $81
+	.byte $80, $00, $00, $80, $80
+	.byte $80, $00, $01, $80, $81
+	.byte $80, $01, $00, $81, $80
+	.byte $80, $01, $01, $81, $81
+	.byte $80, $0F, $00, $8F, $80
+	.byte $80, $0F, $01, $8F, $81
+	.byte $80, $40, $00, $C0, $80
+	.byte $80, $40, $01, $C0, $81
+	.byte $80, $7F, $00, $FF, $80
+	.byte $80, $7F, $01, $FF, $81
+	.byte $80, $80, $00, $80, $80
+	.byte $80, $80, $01, $80, $81
+	.byte $80, $81, $00, $81, $80
+	.byte $80, $81, $01, $81, $81
+	.byte $80, $C0, $00, $C0, $80
+	.byte $80, $C0, $01, $C0, $81
+	.byte $80, $F0, $00, $F0, $80
+	.byte $80, $F0, $01, $F0, $81
+	.byte $80, $FF, $00, $FF, $80
+	.byte $80, $FF, $01, $FF, $81
+	.byte $81, $00, $00, $81, $80
+	.byte $81, $00, $01, $81, $81
+	.byte $81, $01, $00, $81, $80
+	.byte $81, $01, $01, $81, $81
+	.byte $81, $0F, $00, $8F, $80
+	.byte $81, $0F, $01, $8F, $81
+	.byte $81, $40, $00, $C1, $80
+	.byte $81, $40, $01, $C1, $81
+	.byte $81, $7F, $00, $FF, $80
+	.byte $81, $7F, $01, $FF, $81
+	.byte $81, $80, $00, $81, $80
+	.byte $81, $80, $01, $81, $81
+	.byte $81, $81, $00, $81, $80
+	.byte $81, $81, $01, $81, $81
+	.byte $81, $C0, $00, $C1, $80
+	.byte $81, $C0, $01, $C1, $81
+	.byte $81, $F0, $00, $F1, $80
+	.byte $81, $F0, $01, $F1, $81
+	.byte $81, $FF, $00, $FF, $80
+	.byte $81, $FF, $01, $FF, $81
+	.byte $C0, $00, $00, $C0, $80
+	.byte $C0, $00, $01, $C0, $81
+	.byte $C0, $01, $00, $C1, $80
+	.byte $C0, $01, $01, $C1, $81
+	.byte $C0, $0F, $00, $CF, $80
+	.byte $C0, $0F, $01, $CF, $81
+	.byte $C0, $40, $00, $C0, $80
+	.byte $C0, $40, $01, $C0, $81
+	.byte $C0, $7F, $00, $FF, $80
+	.byte $C0, $7F, $01, $FF, $81
+	.byte $C0, $80, $00, $C0, $80
+	.byte $C0, $80, $01, $C0, $81
+	.byte $C0, $81, $00, $C1, $80
+	.byte $C0, $81, $01, $C1, $81
+	.byte $C0, $C0, $00, $C0, $80
+	.byte $C0, $C0, $01, $C0, $81
+	.byte $C0, $F0, $00, $F0, $80
+	.byte $C0, $F0, $01, $F0, $81
+	.byte $C0, $FF, $00, $FF, $80
+	.byte $C0, $FF, $01, $FF, $81
+	.byte $F0, $00, $00, $F0, $80
+	.byte $F0, $00, $01, $F0, $81
+	.byte $F0, $01, $00, $F1, $80
+	.byte $F0, $01, $01, $F1, $81
+	.byte $F0, $0F, $00, $FF, $80
+	.byte $F0, $0F, $01, $FF, $81
+	.byte $F0, $40, $00, $F0, $80
+	.byte $F0, $40, $01, $F0, $81
+	.byte $F0, $7F, $00, $FF, $80
+	.byte $F0, $7F, $01, $FF, $81
+	.byte $F0, $80, $00, $F0, $80
+	.byte $F0, $80, $01, $F0, $81
+	.byte $F0, $81, $00, $F1, $80
+	.byte $F0, $81, $01, $F1, $81
+	.byte $F0, $C0, $00, $F0, $80
+	.byte $F0, $C0, $01, $F0, $81
+	.byte $F0, $F0, $00, $F0, $80
+	.byte $F0, $F0, $01, $F0, $81
+	.byte $F0, $FF, $00, $FF, $80
+	.byte $F0, $FF, $01, $FF, $81
+	.byte $FF, $00, $00, $FF, $80
+	.byte $FF, $00, $01, $FF, $81
+	.byte $FF, $01, $00, $FF, $80
+	.byte $FF, $01, $01, $FF, $81
+	.byte $FF, $0F, $00, $FF, $80
+	.byte $FF, $0F, $01, $FF, $81
+	.byte $FF, $40, $00, $FF, $80
+	.byte $FF, $40, $01, $FF, $81
+	.byte $FF, $7F, $00, $FF, $80
+	.byte $FF, $7F, $01, $FF, $81
+	.byte $FF, $80, $00, $FF, $80
+	.byte $FF, $80, $01, $FF, $81
+	.byte $FF, $81, $00, $FF, $80
+	.byte $FF, $81, $01, $FF, $81
+	.byte $FF, $C0, $00, $FF, $80
+	.byte $FF, $C0, $01, $FF, $81
+	.byte $FF, $F0, $00, $FF, $80
+	.byte $FF, $F0, $01, $FF, $81
+	.byte $FF, $FF, $00, $FF, $80
+	.byte $FF, $FF, $01, $FF, $81
+ora_end:
+eor_table:
+	.byte $00, $00, $00, $00, $02
+	.byte $00, $00, $01, $00, $03
+	.byte $00, $01, $00, $01, $00
+	.byte $00, $01, $01, $01, $01
+	.byte $00, $0F, $00, $0F, $00
+	.byte $00, $0F, $01, $0F, $01
+	.byte $00, $40, $00, $40, $00
+	.byte $00, $40, $01, $40, $01
+	.byte $00, $7F, $00, $7F, $00
+	.byte $00, $7F, $01, $7F, $01
+	.byte $00, $80, $00, $80, $80
+	.byte $00, $80, $01, $80, $81
+	.byte $00, $81, $00, $81, $80
+	.byte $00, $81, $01, $81, $81
+	.byte $00, $C0, $00, $C0, $80
+	.byte $00, $C0, $01, $C0, $81
+	.byte $00, $F0, $00, $F0, $80
+	.byte $00, $F0, $01, $F0, $81
+	.byte $00, $FF, $00, $FF, $80
+	.byte $00, $FF, $01, $FF, $81
+	.byte $01, $00, $00, $01, $00
+	.byte $01, $00, $01, $01, $01
+	.byte $01, $01, $00, $00, $02
+	.byte $01, $01, $01, $00, $03
+	.byte $01, $0F, $00, $0E, $00
+	.byte $01, $0F, $01, $0E, $01
+	.byte $01, $40, $00, $41, $00
+	.byte $01, $40, $01, $41, $01
+	.byte $01, $7F, $00, $7E, $00
+	.byte $01, $7F, $01, $7E, $01
+	.byte $01, $80, $00, $81, $80
+	.byte $01, $80, $01, $81, $81
+	.byte $01, $81, $00, $80, $80
+	.byte $01, $81, $01, $80, $81
+	.byte $01, $C0, $00, $C1, $80
+	.byte $01, $C0, $01, $C1, $81
+	.byte $01, $F0, $00, $F1, $80
+	.byte $01, $F0, $01, $F1, $81
+	.byte $01, $FF, $00, $FE, $80
+	.byte $01, $FF, $01, $FE, $81
+	.byte $0F, $00, $00, $0F, $00
+	.byte $0F, $00, $01, $0F, $01
+	.byte $0F, $01, $00, $0E, $00
+	.byte $0F, $01, $01, $0E, $01
+	.byte $0F, $0F, $00, $00, $02
+	.byte $0F, $0F, $01, $00, $03
+	.byte $0F, $40, $00, $4F, $00
+	.byte $0F, $40, $01, $4F, $01
+	.byte $0F, $7F, $00, $70, $00
+	.byte $0F, $7F, $01, $70, $01
+	.byte $0F, $80, $00, $8F, $80
+	.byte $0F, $80, $01, $8F, $81
+	.byte $0F, $81, $00, $8E, $80
+	.byte $0F, $81, $01, $8E, $81
+	.byte $0F, $C0, $00, $CF, $80
+	.byte $0F, $C0, $01, $CF, $81
+	.byte $0F, $F0, $00, $FF, $80
+	.byte $0F, $F0, $01, $FF, $81
+	.byte $0F, $FF, $00, $F0, $80
+	.byte $0F, $FF, $01, $F0, $81
+	.byte $40, $00, $00, $40, $00
+	.byte $40, $00, $01, $40, $01
+	.byte $40, $01, $00, $41, $00
+	.byte $40, $01, $01, $41, $01
+	.byte $40, $0F, $00, $4F, $00
+	.byte $40, $0F, $01, $4F, $01
+	.byte $40, $40, $00, $00, $02
+	.byte $40, $40, $01, $00, $03
+	.byte $40, $7F, $00, $3F, $00
+	.byte $40, $7F, $01, $3F, $01
+	.byte $40, $80, $00, $C0, $80
+	.byte $40, $80, $01, $C0, $81
+	.byte $40, $81, $00, $C1, $80
+	.byte $40, $81, $01, $C1, $81
+	.byte $40, $C0, $00, $80, $80
+	.byte $40, $C0, $01, $80, $81
+	.byte $40, $F0, $00, $B0, $80
+	.byte $40, $F0, $01, $B0, $81
+	.byte $40, $FF, $00, $BF, $80
+	.byte $40, $FF, $01, $BF, $81
+	.byte $7F, $00, $00, $7F, $00
+	.byte $7F, $00, $01, $7F, $01
+	.byte $7F, $01, $00, $7E, $00
+	.byte $7F, $01, $01, $7E, $01
+	.byte $7F, $0F, $00, $70, $00
+	.byte $7F, $0F, $01, $70, $01
+	.byte $7F, $40, $00, $3F, $00
+	.byte $7F, $40, $01, $3F, $01
+	.byte $7F, $7F, $00, $00, $02
+	.byte $7F, $7F, $01, $00, $03
+	.byte $7F, $80, $00, $FF, $80
+	.byte $7F, $80, $01, $FF, $81
+	.byte $7F, $81, $00, $FE, $80
+	.byte $7F, $81, $01, $FE, $81
+	.byte $7F, $C0, $00, $BF, $80
+	.byte $7F, $C0, $01, $BF, $81
+	.byte $7F, $F0, $00, $8F, $80
+	.byte $7F, $F0, $01, $8F, $81
+	.byte $7F, $FF, $00, $80, $80
+	.byte $7F, $FF, $01, $80, $81
+	.byte $80, $00, $00, $80, $80
+	.byte $80, $00, $01, $80, $81
+	.byte $80, $01, $00, $81, $80
+	.byte $80, $01, $01, $81, $81
+	.byte $80, $0F, $00, $8F, $80
+	.byte $80, $0F, $01, $8F, $81
+	.byte $80, $40, $00, $C0, $80
+	.byte $80, $40, $01, $C0, $81
+	.byte $80, $7F, $00, $FF, $80
+	.byte $80, $7F, $01, $FF, $81
+	.byte $80, $80, $00, $00, $02
+	.byte $80, $80, $01, $00, $03
+	.byte $80, $81, $00, $01, $00
+	.byte $80, $81, $01, $01, $01
+	.byte $80, $C0, $00, $40, $00
+	.byte $80, $C0, $01, $40, $01
+	.byte $80, $F0, $00, $70, $00
+	.byte $80, $F0, $01, $70, $01
+	.byte $80, $FF, $00, $7F, $00
+	.byte $80, $FF, $01, $7F, $01
+	.byte $81, $00, $00, $81, $80
+	.byte $81, $00, $01, $81, $81
+	.byte $81, $01, $00, $80, $80
+	.byte $81, $01, $01, $80, $81
+	.byte $81, $0F, $00, $8E, $80
+	.byte $81, $0F, $01, $8E, $81
+	.byte $81, $40, $00, $C1, $80
+	.byte $81, $40, $01, $C1, $81
+	.byte $81, $7F, $00, $FE, $80
+	.byte $81, $7F, $01, $FE, $81
+	.byte $81, $80, $00, $01, $00
+	.byte $81, $80, $01, $01, $01
+	.byte $81, $81, $00, $00, $02
+	.byte $81, $81, $01, $00, $03
+	.byte $81, $C0, $00, $41, $00
+	.byte $81, $C0, $01, $41, $01
+	.byte $81, $F0, $00, $71, $00
+	.byte $81, $F0, $01, $71, $01
+	.byte $81, $FF, $00, $7E, $00
+	.byte $81, $FF, $01, $7E, $01
+	.byte $C0, $00, $00, $C0, $80
+	.byte $C0, $00, $01, $C0, $81
+	.byte $C0, $01, $00, $C1, $80
+	.byte $C0, $01, $01, $C1, $81
+	.byte $C0, $0F, $00, $CF, $80
+	.byte $C0, $0F, $01, $CF, $81
+	.byte $C0, $40, $00, $80, $80
+	.byte $C0, $40, $01, $80, $81
+	.byte $C0, $7F, $00, $BF, $80
+	.byte $C0, $7F, $01, $BF, $81
+	.byte $C0, $80, $00, $40, $00
+	.byte $C0, $80, $01, $40, $01
+	.byte $C0, $81, $00, $41, $00
+	.byte $C0, $81, $01, $41, $01
+	.byte $C0, $C0, $00, $00, $02
+	.byte $C0, $C0, $01, $00, $03
+	.byte $C0, $F0, $00, $30, $00
+	.byte $C0, $F0, $01, $30, $01
+	.byte $C0, $FF, $00, $3F, $00
+	.byte $C0, $FF, $01, $3F, $01
+	.byte $F0, $00, $00, $F0, $80
+	.byte $F0, $00, $01, $F0, $81
+	.byte $F0, $01, $00, $F1, $80
+	.byte $F0, $01, $01, $F1, $81
+	.byte $F0, $0F, $00, $FF, $80
+	.byte $F0, $0F, $01, $FF, $81
+	.byte $F0, $40, $00, $B0, $80
+	.byte $F0, $40, $01, $B0, $81
+	.byte $F0, $7F, $00, $8F, $80
+	.byte $F0, $7F, $01, $8F, $81
+	.byte $F0, $80, $00, $70, $00
+	.byte $F0, $80, $01, $70, $01
+	.byte $F0, $81, $00, $71, $00
+	.byte $F0, $81, $01, $71, $01
+	.byte $F0, $C0, $00, $30, $00
+	.byte $F0, $C0, $01, $30, $01
+	.byte $F0, $F0, $00, $00, $02
+	.byte $F0, $F0, $01, $00, $03
+	.byte $F0, $FF, $00, $0F, $00
+	.byte $F0, $FF, $01, $0F, $01
+	.byte $FF, $00, $00, $FF, $80
+	.byte $FF, $00, $01, $FF, $81
+	.byte $FF, $01, $00, $FE, $80
+	.byte $FF, $01, $01, $FE, $81
+	.byte $FF, $0F, $00, $F0, $80
+	.byte $FF, $0F, $01, $F0, $81
+	.byte $FF, $40, $00, $BF, $80
+	.byte $FF, $40, $01, $BF, $81
+	.byte $FF, $7F, $00, $80, $80
+	.byte $FF, $7F, $01, $80, $81
+	.byte $FF, $80, $00, $7F, $00
+	.byte $FF, $80, $01, $7F, $01
+	.byte $FF, $81, $00, $7E, $00
+	.byte $FF, $81, $01, $7E, $01
+	.byte $FF, $C0, $00, $3F, $00
+	.byte $FF, $C0, $01, $3F, $01
+	.byte $FF, $F0, $00, $0F, $00
+	.byte $FF, $F0, $01, $0F, $01
+	.byte $FF, $FF, $00, $00, $02
+	.byte $FF, $FF, $01, $00, $03
+eor_end:
+asl_table:
+	.byte $00, $00, $00, $02
+	.byte $00, $01, $00, $02
+	.byte $01, $00, $02, $00
+	.byte $01, $01, $02, $00
+	.byte $0F, $00, $1E, $00
+	.byte $0F, $01, $1E, $00
+	.byte $40, $00, $80, $80
+	.byte $40, $01, $80, $80
+	.byte $7F, $00, $FE, $80
+	.byte $7F, $01, $FE, $80
+	.byte $80, $00, $00, $03
+	.byte $80, $01, $00, $03
+	.byte $81, $00, $02, $01
+	.byte $81, $01, $02, $01
+	.byte $C0, $00, $80, $81
+	.byte $C0, $01, $80, $81
+	.byte $F0, $00, $E0, $81
+	.byte $F0, $01, $E0, $81
+	.byte $FF, $00, $FE, $81
+	.byte $FF, $01, $FE, $81
+asl_end:
+lsr_table:
+	.byte $00, $00, $00, $02
+	.byte $00, $01, $00, $02
+	.byte $01, $00, $00, $03
+	.byte $01, $01, $00, $03
+	.byte $0F, $00, $07, $01
+	.byte $0F, $01, $07, $01
+	.byte $40, $00, $20, $00
+	.byte $40, $01, $20, $00
+	.byte $7F, $00, $3F, $01
+	.byte $7F, $01, $3F, $01
+	.byte $80, $00, $40, $00
+	.byte $80, $01, $40, $00
+	.byte $81, $00, $40, $01
+	.byte $81, $01, $40, $01
+	.byte $C0, $00, $60, $00
+	.byte $C0, $01, $60, $00
+	.byte $F0, $00, $78, $00
+	.byte $F0, $01, $78, $00
+	.byte $FF, $00, $7F, $01
+	.byte $FF, $01, $7F, $01
+lsr_end:
+rol_table:
+	.byte $00, $00, $00, $02
+	.byte $00, $01, $01, $00
+	.byte $01, $00, $02, $00
+	.byte $01, $01, $03, $00
+	.byte $0F, $00, $1E, $00
+	.byte $0F, $01, $1F, $00
+	.byte $40, $00, $80, $80
+	.byte $40, $01, $81, $80
+	.byte $7F, $00, $FE, $80
+	.byte $7F, $01, $FF, $80
+	.byte $80, $00, $00, $03
+	.byte $80, $01, $01, $01
+	.byte $81, $00, $02, $01
+	.byte $81, $01, $03, $01
+	.byte $C0, $00, $80, $81
+	.byte $C0, $01, $81, $81
+	.byte $F0, $00, $E0, $81
+	.byte $F0, $01, $E1, $81
+	.byte $FF, $00, $FE, $81
+	.byte $FF, $01, $FF, $81
+rol_end:
+ror_table:
+	.byte $00, $00, $00, $02
+	.byte $00, $01, $80, $80
+	.byte $01, $00, $00, $03
+	.byte $01, $01, $80, $81
+	.byte $0F, $00, $07, $01
+	.byte $0F, $01, $87, $81
+	.byte $40, $00, $20, $00
+	.byte $40, $01, $A0, $80
+	.byte $7F, $00, $3F, $01
+	.byte $7F, $01, $BF, $81
+	.byte $80, $00, $40, $00
+	.byte $80, $01, $C0, $80
+	.byte $81, $00, $40, $01
+	.byte $81, $01, $C0, $81
+	.byte $C0, $00, $60, $00
+	.byte $C0, $01, $E0, $80
+	.byte $F0, $00, $78, $00
+	.byte $F0, $01, $F8, $80
+	.byte $FF, $00, $7F, $01
+	.byte $FF, $01, $FF, $81
+ror_end:
+
+	.org $FFFA
+	.word start ; NMI
+	.word start ; RESET
+	.word brk_handler ; IRQ and BRK
@@ -0,0 +1,343 @@
+; Interrupt test for the NMOS 6502, written for this emulator.
+; The program raises IRQ and NMI on itself through the feedback register, bit 0 drives IRQ and
+; bit 1 drives NMI. Every test stores its number in test_case and traps (jumps to itself) on the
+; first check that fails, the program traps at success once every test passed.
+;
+; Assemble with vasm6502_oldstyle: vasm6502_oldstyle -Fbin -dotdir interrupt_test.s
+; and load the image at $0400, execution starts at $0400.
+
+feedback = $BFFC
+test_case = $0200
+irq_count = $10
+nmi_count = $11
+brk_count = $12
+irq_remaining = $13 ; the IRQ handler releases the line when this reaches 0
+irq_p = $14 ; the P pushed by the last IRQ or BRK
+irq_return = $15 ; 2 bytes, the address pushed by the last IRQ or BRK
+nmi_p = $17
+nmi_return = $18 ; 2 bytes
+
+	.org $0400
+
+start:
+	cld
+	sei
+	ldx #$FF
+	txs
+	lda #$00
+	sta feedback
+	lda #0
+	sta irq_count
+	sta nmi_count
+	sta brk_count
+
+; IRQ is masked while I is set
+test01:
+	lda #$01
+	sta test_case
+	lda #1
+	sta irq_remaining
+	lda #$01
+	sta feedback
+	nop
+	nop
+	lda irq_count
+	cmp #$00
+	beq *+5
+	jmp * ; trap: failed
+	lda #$00
+	sta feedback
+
+; IRQ is taken one instruction after CLI
+test02:
+	lda #$02
+	sta test_case
+	lda #1
+	sta irq_remaining
+	lda #$01
+	sta feedback
+	cli
+	nop ; still runs with the IRQ pending
+after_cli:
+	sei
+	lda irq_count
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_return
+	cmp #<after_cli
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_return+1
+	cmp #>after_cli
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_p
+	and #$14
+	cmp #$00 ; B and I clear in the pushed P
+	beq *+5
+	jmp * ; trap: failed
+
+; IRQ is level triggered, it is taken again while the line is held
+test03:
+	lda #$03
+	sta test_case
+	lda #0
+	sta irq_count
+	sta nmi_count
+	sta brk_count
+	lda #3
+	sta irq_remaining
+	lda #$01
+	sta feedback
+	cli
+	nop
+	nop
+	sei
+	lda irq_count
+	cmp #$03
+	beq *+5
+	jmp * ; trap: failed
+	lda feedback
+	cmp #$00
+	beq *+5
+	jmp * ; trap: failed
+
+; SEI right after CLI still lets a pending IRQ in, with I set in the pushed P
+test04:
+	lda #$04
+	sta test_case
+	lda #0
+	sta irq_count
+	sta nmi_count
+	sta brk_count
+	lda #1
+	sta irq_remaining
+	lda #$01
+	sta feedback
+	cli
+	sei
+after_sei:
+	nop
+	lda irq_count
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_return
+	cmp #<after_sei
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_return+1
+	cmp #>after_sei
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_p
+	and #$14
+	cmp #$04
+	beq *+5
+	jmp * ; trap: failed
+
+; PLP clearing I delays the IRQ by one instruction too
+test05:
+	lda #$05
+	sta test_case
+	lda #0
+	sta irq_count
+	sta nmi_count
+	sta brk_count
+	lda #1
+	sta irq_remaining
+	lda #$01
+	sta feedback
+	lda #$00
+	pha
+	plp
+	nop
+after_plp:
+	sei
+	lda irq_count
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_return
+	cmp #<after_plp
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_return+1
+	cmp #>after_plp
+	beq *+5
+	jmp * ; trap: failed
+
+; BRK goes through the IRQ vector with B set in the pushed P
+test06:
+	lda #$06
+	sta test_case
+	lda #0
+	sta irq_count
+	sta nmi_count
+	sta brk_count
+	brk
+	.byte $EA
+after_brk:
+	lda brk_count
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_count
+	cmp #$00
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_return
+	cmp #<after_brk
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_return+1
+	cmp #>after_brk
+	beq *+5
+	jmp * ; trap: failed
+	lda irq_p
+	and #$10
+	bne *+5
+	jmp * ; trap: failed
+
+; NMI is taken once per edge, held or not, masked or not
+test07:
+	lda #$07
+	sta test_case
+	lda #0
+	sta irq_count
+	sta nmi_count
+	sta brk_count
+	lda #$02
+	sta feedback
+	nop
+	nop
+	lda nmi_count
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	lda #$00
+	sta feedback
+	nop
+	lda nmi_count
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	lda #$02
+	sta feedback
+after_nmi:
+	nop
+	lda nmi_count
+	cmp #$02
+	beq *+5
+	jmp * ; trap: failed
+	lda nmi_return
+	cmp #<after_nmi
+	beq *+5
+	jmp * ; trap: failed
+	lda nmi_return+1
+	cmp #>after_nmi
+	beq *+5
+	jmp * ; trap: failed
+	lda nmi_p
+	and #$14
+	cmp #$04 ; B clear, I as it was
+	beq *+5
+	jmp * ; trap: failed
+	lda #$00
+	sta feedback
+
+; NMI interrupts the IRQ handler
+test08:
+	lda #$08
+	sta test_case
+	lda #0
+	sta irq_count
+	sta nmi_count
+	sta brk_count
+	lda #1
+	sta irq_remaining
+	lda #$80 ; make the IRQ handler raise NMI
+	sta nmi_from_irq
+	lda #$01
+	sta feedback
+	cli
+	nop
+	sei
+	lda irq_count
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	lda nmi_count
+	cmp #$01
+	beq *+5
+	jmp * ; trap: failed
+	lda #0
+	sta nmi_from_irq
+	lda #$00
+	sta feedback
+
+success:
+	jmp success ; trap: every test passed
+
+nmi_from_irq:
+	.byte 0
+
+irq_handler:
+	pha
+	txa
+	pha
+	tsx
+	lda $0103,x
+	sta irq_p
+	lda $0104,x
+	sta irq_return
+	lda $0105,x
+	sta irq_return+1
+	lda irq_p
+	and #$10
+	beq irq_line
+	inc brk_count
+	jmp irq_exit
+irq_line:
+	inc irq_count
+	bit nmi_from_irq
+	bpl irq_release
+	lda #$03
+	sta feedback
+	nop
+	lda #$01
+	sta feedback
+irq_release:
+	dec irq_remaining
+	bne irq_exit
+	lda feedback
+	and #$FE
+	sta feedback
+irq_exit:
+	pla
+	tax
+	pla
+	rti
+
+nmi_handler:
+	pha
+	txa
+	pha
+	tsx
+	lda $0103,x
+	sta nmi_p
+	lda $0104,x
+	sta nmi_return
+	lda $0105,x
+	sta nmi_return+1
+	inc nmi_count
+	pla
+	tax
+	pla
+	rti
+
+	.org $FFFA
+	.word nmi_handler
+	.word start
+	.word irq_handler
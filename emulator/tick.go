@@ -0,0 +1,108 @@
+package emulator
+
+// SetCycleAccurate turns cycle accurate mode on or off, it is off by default.
+// In cycle accurate mode every cycle is exactly one bus access, made in the order the 6502 makes them,
+// including the dummy reads and writes it does while it works something out. Read-modify-write instructions write
+// the original value back before the modified one, indexed addressing reads the address before the carry into the high
+// byte is fixed up, and so on. This is slower, but devices that react to reads see what they would on the real chip.
+func (cpu *CPU) SetCycleAccurate(enabled bool) {
+	cpu.cycleAccurate = enabled
+}
+
+// Tick runs the CPU for a single cycle, so devices can be clocked in lockstep with it.
+// The first call turns on cycle accurate mode and starts a goroutine that runs the CPU, from then on the CPU is driven
+// by Tick alone, don't mix it with Step or the Run methods until StopTicking. An error from Step, like ErrHalted,
+// is returned by the tick it happened on.
+//
+// Between ticks the goroutine is parked in the middle of an instruction, call StopTicking before dropping the CPU
+// or both are leaked.
+func (cpu *CPU) Tick() error {
+	if cpu.ticks == nil {
+		cpu.cycleAccurate = true
+		cpu.ticks = make(chan struct{})
+		cpu.tocks = make(chan error)
+		go cpu.tickLoop()
+	}
+
+	cpu.ticks <- struct{}{}
+	return <-cpu.tocks
+}
+
+// StopTicking stops the goroutine started by Tick. The instruction in progress is run to the end without waiting
+// for ticks, so the CPU is left between instructions and can be driven by Step or the Run methods again.
+// It must be called from the goroutine that calls Tick, a later Tick starts over.
+func (cpu *CPU) StopTicking() {
+	if cpu.ticks == nil {
+		return
+	}
+
+	close(cpu.ticks)
+	for range cpu.tocks {
+	}
+
+	cpu.ticks = nil
+	cpu.tocks = nil
+	cpu.cycleOpen = false
+	cpu.ticksClosed = false
+}
+
+// tickLoop steps the CPU, each of its cycles waits for a tick in waitTick. It returns once StopTicking closes ticks.
+func (cpu *CPU) tickLoop() {
+	defer close(cpu.tocks)
+
+	if !cpu.awaitTick() {
+		return
+	}
+
+	for !cpu.ticksClosed {
+		if err := cpu.Step(); err != nil && !cpu.ticksClosed {
+			cpu.tocks <- err
+			cpu.awaitTick()
+			cpu.cycleOpen = false
+		}
+	}
+}
+
+// awaitTick waits for the next tick, it returns false once ticks has been closed.
+func (cpu *CPU) awaitTick() bool {
+	if _, ok := <-cpu.ticks; !ok {
+		cpu.ticksClosed = true
+		return false
+	}
+
+	return true
+}
+
+// waitTick is called at the start of every cycle while the CPU is driven by Tick.
+// The tick that started the previous cycle is only answered here, so the CPU is left alone between ticks.
+func (cpu *CPU) waitTick() {
+	if cpu.cycleOpen && !cpu.ticksClosed {
+		cpu.tocks <- nil
+		cpu.awaitTick()
+	}
+	cpu.cycleOpen = true
+}
+
+// stall waits out the cycles RDY is asserted for, it is called once the cycle of a bus access has started so that
+// releasing RDY between ticks lets the access happen on the next one.
+func (cpu *CPU) stall() {
+	for cpu.ready.Asserted() {
+		cpu.Pulse()
+	}
+}
+
+// dummyRead makes a read whose data the CPU throws away, it only happens in cycle accurate mode
+// and doesn't trigger watchpoints.
+func (cpu *CPU) dummyRead(address uint16) {
+	if cpu.cycleAccurate {
+		cpu.readBus(address)
+	}
+}
+
+// dummyWrite makes a write the CPU overwrites straight away, it only happens in cycle accurate mode
+// and doesn't trigger watchpoints.
+func (cpu *CPU) dummyWrite(address uint16, data uint8) {
+	if cpu.cycleAccurate {
+		cpu.writeBus(address, data)
+	}
+}
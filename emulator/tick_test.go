@@ -0,0 +1,121 @@
+package emulator
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStopTicking(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+
+	// LDA $1234 ; NOP
+	cpu, ram := newTestCPU(t, MOS6502, 0xAD, 0x34, 0x12, 0xEA)
+	ram[0x1234] = 0x42
+
+	// Stop half way through the LDA
+	for i := 0; i < 2; i++ {
+		if err := cpu.Tick(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cpu.StopTicking()
+
+	if state := cpu.State(); state.PC != 0x0203 || state.A != 0x42 {
+		t.Errorf("got pc $%04X A=$%02X, want the LDA finished at $0203 with A=$42", state.PC, state.A)
+	}
+
+	// The goroutine exits asynchronously after closing tocks
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Errorf("%d goroutines running after StopTicking, want %d", n, goroutines)
+	}
+
+	step(t, cpu)
+	if pc := cpu.State().PC; pc != 0x0204 {
+		t.Errorf("got pc $%04X after stepping the NOP, want $0204", pc)
+	}
+
+	// Ticking again starts a new goroutine
+	if err := cpu.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	cpu.StopTicking()
+}
+
+// accessLog records the bus accesses the CPU makes.
+type accessLog struct {
+	NopObserver
+	accesses []BusAccess
+}
+
+func (l *accessLog) OnBusAccess(cpu *CPU, access BusAccess) {
+	l.accesses = append(l.accesses, access)
+}
+
+func TestReadyStallsReads(t *testing.T) {
+	tests := []struct {
+		variant     Variant
+		stallsWrite bool
+	}{
+		{MOS6502, false},
+		{WDC65C02, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.variant.String(), func(t *testing.T) {
+			// STA $1000 ; LDA $1000
+			cpu, ram := newTestCPU(t, test.variant, 0x8D, 0x00, 0x10, 0xAD, 0x00, 0x10)
+			cpu.SetState(State{PC: 0x0200, SP: 0xFF, A: 0x42, P: p(0)})
+			log := &accessLog{}
+			cpu.AddObserver(log)
+			defer cpu.StopTicking()
+
+			tick := func() {
+				t.Helper()
+				if err := cpu.Tick(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The STA's opcode and operand, then RDY is pulled low before its write cycle
+			for i := 0; i < 3; i++ {
+				tick()
+			}
+			ready := cpu.RDY().NewSource()
+			ready.Assert()
+
+			// The NMOS 6502 only stalls on reads, so the write completes and it stops at the next fetch
+			accesses := 3
+			if !test.stallsWrite {
+				tick()
+				accesses++
+				if ram[0x1000] != 0x42 {
+					t.Fatalf("got $%02X at $1000, want the write to complete while RDY is asserted", ram[0x1000])
+				}
+			}
+			for i := 0; i < 3; i++ {
+				tick()
+			}
+			if len(log.accesses) != accesses {
+				t.Fatalf("made %d accesses with RDY asserted, want %d", len(log.accesses), accesses)
+			}
+
+			// Released, the stalled access happens on the next tick
+			ready.Release()
+			tick()
+			if len(log.accesses) != accesses+1 {
+				t.Fatalf("made %d accesses after releasing RDY, want %d", len(log.accesses), accesses+1)
+			}
+			if last := log.accesses[len(log.accesses)-1]; test.stallsWrite != last.Write {
+				t.Errorf("got %v after releasing RDY, want the stalled access", last)
+			}
+			if cycles := cpu.State().Cycles; cycles != uint64(accesses+4) {
+				t.Errorf("got %d cycles, want the 3 stalled ones counted", cycles)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package emulator
+
+// The stable undocumented opcodes of the NMOS 6502.
+// They fall out of the way the instruction decoder is built, most of them run two documented instructions at once.
+// The unstable ones (XAA, LXA, SHA, SHX, SHY, TAS) depend on analog effects of the chip and are not emulated.
+var UndocumentedOpCodeMap = map[uint8]OpCode{
+	0x4B: {ALR, Immediate, 2}, // ALR
+
+	0x0B: {ANC, Immediate, 2}, // ANC
+	0x2B: {ANC, Immediate, 2}, // ANC
+
+	0x6B: {ARR, Immediate, 2}, // ARR
+
+	0xC7: {DCP, ZeroPage, 5},  // DCP
+	0xD7: {DCP, ZeroPageX, 6}, // DCP
+	0xCF: {DCP, Absolute, 6},  // DCP
+	0xDF: {DCP, AbsoluteX, 7}, // DCP
+	0xDB: {DCP, AbsoluteY, 7}, // DCP
+	0xC3: {DCP, IndirectX, 8}, // DCP
+	0xD3: {DCP, IndirectY, 8}, // DCP
+
+	0xE7: {ISC, ZeroPage, 5},  // ISC
+	0xF7: {ISC, ZeroPageX, 6}, // ISC
+	0xEF: {ISC, Absolute, 6},  // ISC
+	0xFF: {ISC, AbsoluteX, 7}, // ISC
+	0xFB: {ISC, AbsoluteY, 7}, // ISC
+	0xE3: {ISC, IndirectX, 8}, // ISC
+	0xF3: {ISC, IndirectY, 8}, // ISC
+
+	0x02: {JAM, Implicit, 2}, // JAM
+	0x12: {JAM, Implicit, 2}, // JAM
+	0x22: {JAM, Implicit, 2}, // JAM
+	0x32: {JAM, Implicit, 2}, // JAM
+	0x42: {JAM, Implicit, 2}, // JAM
+	0x52: {JAM, Implicit, 2}, // JAM
+	0x62: {JAM, Implicit, 2}, // JAM
+	0x72: {JAM, Implicit, 2}, // JAM
+	0x92: {JAM, Implicit, 2}, // JAM
+	0xB2: {JAM, Implicit, 2}, // JAM
+	0xD2: {JAM, Implicit, 2}, // JAM
+	0xF2: {JAM, Implicit, 2}, // JAM
+
+	0xBB: {LAS, AbsoluteY, 4}, // LAS
+
+	0xA7: {LAX, ZeroPage, 3},  // LAX
+	0xB7: {LAX, ZeroPageY, 4}, // LAX
+	0xAF: {LAX, Absolute, 4},  // LAX
+	0xBF: {LAX, AbsoluteY, 4}, // LAX
+	0xA3: {LAX, IndirectX, 6}, // LAX
+	0xB3: {LAX, IndirectY, 5}, // LAX
+
+	0x1A: {NOP, Implicit, 2},  // NOP
+	0x3A: {NOP, Implicit, 2},  // NOP
+	0x5A: {NOP, Implicit, 2},  // NOP
+	0x7A: {NOP, Implicit, 2},  // NOP
+	0xDA: {NOP, Implicit, 2},  // NOP
+	0xFA: {NOP, Implicit, 2},  // NOP
+	0x80: {NOP, Immediate, 2}, // NOP
+	0x82: {NOP, Immediate, 2}, // NOP
+	0x89: {NOP, Immediate, 2}, // NOP
+	0xC2: {NOP, Immediate, 2}, // NOP
+	0xE2: {NOP, Immediate, 2}, // NOP
+	0x04: {NOP, ZeroPage, 3},  // NOP
+	0x44: {NOP, ZeroPage, 3},  // NOP
+	0x64: {NOP, ZeroPage, 3},  // NOP
+	0x14: {NOP, ZeroPageX, 4}, // NOP
+	0x34: {NOP, ZeroPageX, 4}, // NOP
+	0x54: {NOP, ZeroPageX, 4}, // NOP
+	0x74: {NOP, ZeroPageX, 4}, // NOP
+	0xD4: {NOP, ZeroPageX, 4}, // NOP
+	0xF4: {NOP, ZeroPageX, 4}, // NOP
+	0x0C: {NOP, Absolute, 4},  // NOP
+	0x1C: {NOP, AbsoluteX, 4}, // NOP
+	0x3C: {NOP, AbsoluteX, 4}, // NOP
+	0x5C: {NOP, AbsoluteX, 4}, // NOP
+	0x7C: {NOP, AbsoluteX, 4}, // NOP
+	0xDC: {NOP, AbsoluteX, 4}, // NOP
+	0xFC: {NOP, AbsoluteX, 4}, // NOP
+
+	0x27: {RLA, ZeroPage, 5},  // RLA
+	0x37: {RLA, ZeroPageX, 6}, // RLA
+	0x2F: {RLA, Absolute, 6},  // RLA
+	0x3F: {RLA, AbsoluteX, 7}, // RLA
+	0x3B: {RLA, AbsoluteY, 7}, // RLA
+	0x23: {RLA, IndirectX, 8}, // RLA
+	0x33: {RLA, IndirectY, 8}, // RLA
+
+	0x67: {RRA, ZeroPage, 5},  // RRA
+	0x77: {RRA, ZeroPageX, 6}, // RRA
+	0x6F: {RRA, Absolute, 6},  // RRA
+	0x7F: {RRA, AbsoluteX, 7}, // RRA
+	0x7B: {RRA, AbsoluteY, 7}, // RRA
+	0x63: {RRA, IndirectX, 8}, // RRA
+	0x73: {RRA, IndirectY, 8}, // RRA
+
+	0x87: {SAX, ZeroPage, 3},  // SAX
+	0x97: {SAX, ZeroPageY, 4}, // SAX
+	0x8F: {SAX, Absolute, 4},  // SAX
+	0x83: {SAX, IndirectX, 6}, // SAX
+
+	0xEB: {SBC, Immediate, 2}, // SBC (USBC)
+
+	0xCB: {SBX, Immediate, 2}, // SBX
+
+	0x07: {SLO, ZeroPage, 5},  // SLO
+	0x17: {SLO, ZeroPageX, 6}, // SLO
+	0x0F: {SLO, Absolute, 6},  // SLO
+	0x1F: {SLO, AbsoluteX, 7}, // SLO
+	0x1B: {SLO, AbsoluteY, 7}, // SLO
+	0x03: {SLO, IndirectX, 8}, // SLO
+	0x13: {SLO, IndirectY, 8}, // SLO
+
+	0x47: {SRE, ZeroPage, 5},  // SRE
+	0x57: {SRE, ZeroPageX, 6}, // SRE
+	0x4F: {SRE, Absolute, 6},  // SRE
+	0x5F: {SRE, AbsoluteX, 7}, // SRE
+	0x5B: {SRE, AbsoluteY, 7}, // SRE
+	0x43: {SRE, IndirectX, 8}, // SRE
+	0x53: {SRE, IndirectY, 8}, // SRE
+}
+
+// ALR is AND immediate followed by LSR A
+func (i iInstructionSet) ALR(cpu *CPU, mode MemoryMode) {
+	val, _, _ := i.MemoryMode(cpu, mode, true)
+
+	val &= cpu.registers.A
+
+	cpu.flags.Carry = val&0x01 == 0x01
+	cpu.registers.A = val >> 1
+
+	cpu.flags.Zero = cpu.registers.A == 0
+	cpu.flags.Negative = false
+}
+
+// ANC is AND immediate with bit 7 of the result copied into carry
+func (i iInstructionSet) ANC(cpu *CPU, mode MemoryMode) {
+	val, _, _ := i.MemoryMode(cpu, mode, true)
+
+	cpu.registers.A &= val
+
+	cpu.flags.Zero = cpu.registers.A == 0
+	cpu.flags.Negative = cpu.registers.A&0x80 != 0
+	cpu.flags.Carry = cpu.flags.Negative
+}
+
+// ARR is AND immediate followed by ROR A, with the flags coming out of the adder.
+// In decimal mode the result gets a BCD fixup similar to ADC.
+func (i iInstructionSet) ARR(cpu *CPU, mode MemoryMode) {
+	val, _, _ := i.MemoryMode(cpu, mode, true)
+
+	val &= cpu.registers.A
+	result := val >> 1
+	if cpu.flags.Carry {
+		result |= 0x80
+	}
+
+	cpu.flags.Zero = result == 0
+	cpu.flags.Negative = result&0x80 != 0
+
+	if !cpu.flags.Decimal {
+		cpu.flags.Carry = result&0x40 != 0
+		cpu.flags.Overflow = (result>>6)&0x01 != (result>>5)&0x01
+		cpu.registers.A = result
+
+		return
+	}
+
+	cpu.flags.Overflow = (val^result)&0x40 != 0
+
+	if (val&0x0F)+(val&0x01) > 0x05 {
+		result = result&0xF0 | (result+0x06)&0x0F
+	}
+
+	cpu.flags.Carry = uint16(val&0xF0)+uint16(val&0x10) > 0x50
+	if cpu.flags.Carry {
+		result += 0x60
+	}
+
+	cpu.registers.A = result
+}
+
+// DCP is DEC followed by CMP
+func (i iInstructionSet) DCP(cpu *CPU, mode MemoryMode) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+
+	val--
+	cpu.write(addr, val)
+
+	i.compare(cpu, cpu.registers.A, val)
+}
+
+// ISC is INC followed by SBC
+func (i iInstructionSet) ISC(cpu *CPU, mode MemoryMode) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+
+	val++
+	cpu.write(addr, val)
+
+	i.subtract(cpu, val)
+}
+
+// JAM locks up the CPU, it stops fetching instructions until the next reset
+func (i iInstructionSet) JAM(cpu *CPU) {
+	cpu.halted = true
+}
+
+// LAS loads A, X and the stack pointer with memory ANDed with the stack pointer
+func (i iInstructionSet) LAS(cpu *CPU, mode MemoryMode) {
+	val, _, _ := i.MemoryMode(cpu, mode, true)
+
+	val &= uint8(cpu.stackPointer)
+	cpu.registers.A = val
+	cpu.registers.X = val
+	cpu.stackPointer = StackPointer(val)
+
+	cpu.flags.Zero = val == 0
+	cpu.flags.Negative = val&0x80 != 0
+}
+
+// LAX is LDA and LDX at once
+func (i iInstructionSet) LAX(cpu *CPU, mode MemoryMode) {
+	val, _, _ := i.MemoryMode(cpu, mode, true)
+
+	cpu.registers.A = val
+	cpu.registers.X = val
+
+	cpu.flags.Zero = val == 0
+	cpu.flags.Negative = val&0x80 != 0
+}
+
+// RLA is ROL followed by AND
+func (i iInstructionSet) RLA(cpu *CPU, mode MemoryMode) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+
+	carry := cpu.flags.Carry
+	cpu.flags.Carry = val&0x80 == 0x80
+	val <<= 1
+	if carry {
+		val |= 0x01
+	}
+	cpu.write(addr, val)
+
+	cpu.registers.A &= val
+
+	cpu.flags.Zero = cpu.registers.A == 0
+	cpu.flags.Negative = cpu.registers.A&0x80 != 0
+}
+
+// RRA is ROR followed by ADC
+func (i iInstructionSet) RRA(cpu *CPU, mode MemoryMode) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+
+	carry := cpu.flags.Carry
+	cpu.flags.Carry = val&0x01 == 0x01
+	val >>= 1
+	if carry {
+		val |= 0x80
+	}
+	cpu.write(addr, val)
+
+	i.add(cpu, val)
+}
+
+// SAX stores A ANDed with X
+func (i iInstructionSet) SAX(cpu *CPU, mode MemoryMode) {
+	_, addr, _ := i.MemoryMode(cpu, mode, false)
+	cpu.write(addr, cpu.registers.A&cpu.registers.X)
+}
+
+// SBX sets X to A ANDed with X minus the immediate value, without borrow
+func (i iInstructionSet) SBX(cpu *CPU, mode MemoryMode) {
+	val, _, _ := i.MemoryMode(cpu, mode, true)
+
+	ax := cpu.registers.A & cpu.registers.X
+	i.compare(cpu, ax, val)
+	cpu.registers.X = ax - val
+}
+
+// SLO is ASL followed by ORA
+func (i iInstructionSet) SLO(cpu *CPU, mode MemoryMode) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+
+	cpu.flags.Carry = val > 0x7F
+	val <<= 1
+	cpu.write(addr, val)
+
+	cpu.registers.A |= val
+
+	cpu.flags.Zero = cpu.registers.A == 0
+	cpu.flags.Negative = cpu.registers.A&0x80 != 0
+}
+
+// SRE is LSR followed by EOR
+func (i iInstructionSet) SRE(cpu *CPU, mode MemoryMode) {
+	val, addr, _ := i.MemoryMode(cpu, mode, true)
+
+	cpu.flags.Carry = val&0x01 == 0x01
+	val >>= 1
+	cpu.write(addr, val)
+
+	cpu.registers.A ^= val
+
+	cpu.flags.Zero = cpu.registers.A == 0
+	cpu.flags.Negative = cpu.registers.A&0x80 != 0
+}
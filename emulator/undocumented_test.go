@@ -0,0 +1,100 @@
+package emulator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUndocumentedOpcodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		program []uint8
+		before  State
+		memory  map[uint16]uint8
+		want    State // PC, A, X, Y and P are compared
+		written map[uint16]uint8
+		cycles  uint64
+	}{
+		{"LAX zp", []uint8{0xA7, 0x10}, State{}, map[uint16]uint8{0x10: 0x80}, State{PC: 0x0202, A: 0x80, X: 0x80, P: p(flagN)}, nil, 3},
+		{"LAX abs,Y across a page", []uint8{0xBF, 0xF0, 0x10}, State{A: 0x12, X: 0x34, Y: 0x10}, nil, State{PC: 0x0203, Y: 0x10, P: p(flagZ)}, nil, 5},
+		{"SAX zp", []uint8{0x87, 0x10}, State{A: 0xF0, X: 0x3C, P: p(flagN)}, nil, State{PC: 0x0202, A: 0xF0, X: 0x3C, P: p(flagN)}, map[uint16]uint8{0x10: 0x30}, 3},
+		{"DCP zp", []uint8{0xC7, 0x10}, State{A: 0x42}, map[uint16]uint8{0x10: 0x43}, State{PC: 0x0202, A: 0x42, P: p(flagZ | flagC)}, map[uint16]uint8{0x10: 0x42}, 5},
+		{"DCP abs,X", []uint8{0xDF, 0x00, 0x10}, State{A: 0x10, X: 0x01}, map[uint16]uint8{0x1001: 0x00}, State{PC: 0x0203, A: 0x10, X: 0x01, P: p(0)}, map[uint16]uint8{0x1001: 0xFF}, 7},
+		{"ISC zp", []uint8{0xE7, 0x10}, State{A: 0x20, P: p(flagC)}, map[uint16]uint8{0x10: 0x0F}, State{PC: 0x0202, A: 0x10, P: p(flagC)}, map[uint16]uint8{0x10: 0x10}, 5},
+		{"SLO zp", []uint8{0x07, 0x10}, State{A: 0x02}, map[uint16]uint8{0x10: 0x81}, State{PC: 0x0202, A: 0x02, P: p(flagC)}, map[uint16]uint8{0x10: 0x02}, 5},
+		{"RLA zp", []uint8{0x27, 0x10}, State{A: 0xFF, P: p(flagC)}, map[uint16]uint8{0x10: 0x81}, State{PC: 0x0202, A: 0x03, P: p(flagC)}, map[uint16]uint8{0x10: 0x03}, 5},
+		{"SRE zp", []uint8{0x47, 0x10}, State{A: 0x01}, map[uint16]uint8{0x10: 0x03}, State{PC: 0x0202, A: 0x00, P: p(flagZ | flagC)}, map[uint16]uint8{0x10: 0x01}, 5},
+		{"RRA zp", []uint8{0x67, 0x10}, State{A: 0x10, P: p(flagC)}, map[uint16]uint8{0x10: 0x02}, State{PC: 0x0202, A: 0x91, P: p(flagN)}, map[uint16]uint8{0x10: 0x81}, 5},
+		{"SLO (zp),Y", []uint8{0x13, 0x10}, State{Y: 0x01}, map[uint16]uint8{0x10: 0x00, 0x11: 0x10, 0x1001: 0x40}, State{PC: 0x0202, A: 0x80, Y: 0x01, P: p(flagN)}, map[uint16]uint8{0x1001: 0x80}, 8},
+		{"ANC #", []uint8{0x0B, 0x80}, State{A: 0xFF}, nil, State{PC: 0x0202, A: 0x80, P: p(flagN | flagC)}, nil, 2},
+		{"ALR #", []uint8{0x4B, 0x03}, State{A: 0xFF}, nil, State{PC: 0x0202, A: 0x01, P: p(flagC)}, nil, 2},
+		{"ARR # sets N and C", []uint8{0x6B, 0xFF}, State{A: 0xFF, P: p(flagC)}, nil, State{PC: 0x0202, A: 0xFF, P: p(flagN | flagC)}, nil, 2},
+		{"ARR # sets V from bits 6 and 5", []uint8{0x6B, 0xFF}, State{A: 0x80}, nil, State{PC: 0x0202, A: 0x40, P: p(flagV | flagC)}, nil, 2},
+		{"SBX #", []uint8{0xCB, 0x02}, State{A: 0x0F, X: 0xF3}, nil, State{PC: 0x0202, A: 0x0F, X: 0x01, P: p(flagC)}, nil, 2},
+		{"SBX # borrows", []uint8{0xCB, 0x04}, State{A: 0x0F, X: 0xF3}, nil, State{PC: 0x0202, A: 0x0F, X: 0xFF, P: p(flagN)}, nil, 2},
+
+		// The NOPs only skip their operand and spend the cycles of their addressing mode
+		{"NOP implied", []uint8{0x1A}, State{}, nil, State{PC: 0x0201, P: p(0)}, nil, 2},
+		{"NOP #", []uint8{0x80, 0xFF}, State{}, nil, State{PC: 0x0202, P: p(0)}, nil, 2},
+		{"NOP zp", []uint8{0x04, 0x10}, State{}, nil, State{PC: 0x0202, P: p(0)}, nil, 3},
+		{"NOP zp,X", []uint8{0x14, 0x10}, State{}, nil, State{PC: 0x0202, P: p(0)}, nil, 4},
+		{"NOP abs", []uint8{0x0C, 0x00, 0x10}, State{}, nil, State{PC: 0x0203, P: p(0)}, nil, 4},
+		{"NOP abs,X", []uint8{0x1C, 0xF0, 0x10}, State{X: 0x0F}, nil, State{PC: 0x0203, X: 0x0F, P: p(0)}, nil, 4},
+		{"NOP abs,X across a page", []uint8{0x1C, 0xF0, 0x10}, State{X: 0x10}, nil, State{PC: 0x0203, X: 0x10, P: p(0)}, nil, 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, ram := newTestCPU(t, MOS6502, test.program...)
+			for address, data := range test.memory {
+				ram[address] = data
+			}
+
+			before := test.before
+			before.PC = 0x0200
+			before.SP = 0xFF
+			if before.P == 0 {
+				before.P = p(0)
+			}
+			cpu.SetState(before)
+
+			step(t, cpu)
+
+			got := cpu.State()
+			want := test.want
+			if got.PC != want.PC || got.A != want.A || got.X != want.X || got.Y != want.Y || got.P != want.P {
+				t.Errorf("got PC=%04X A=%02X X=%02X Y=%02X P=%08b, want PC=%04X A=%02X X=%02X Y=%02X P=%08b",
+					got.PC, got.A, got.X, got.Y, got.P, want.PC, want.A, want.X, want.Y, want.P)
+			}
+			for address, data := range test.written {
+				if ram[address] != data {
+					t.Errorf("got $%02X at $%04X, want $%02X", ram[address], address, data)
+				}
+			}
+			if got.Cycles != test.cycles {
+				t.Errorf("took %d cycles, want %d", got.Cycles, test.cycles)
+			}
+		})
+	}
+}
+
+func TestSetUndocumentedOff(t *testing.T) {
+	for _, opcode := range []uint8{0xA7, 0x87, 0xC7, 0xE7, 0x07, 0x27, 0x47, 0x67, 0x0B, 0x4B, 0x6B, 0xCB, 0x1A, 0x80, 0x04, 0x0C} {
+		// LAX $10 and friends, with the unknown opcode policy failing the step
+		cpu, _ := newTestCPU(t, MOS6502, opcode, 0x10, 0x10)
+		cpu.SetUndocumented(false)
+		cpu.SetUnknownOpcodePolicy(UnknownOpcodeFail)
+
+		var unknown *UnknownOpcodeError
+		if err := cpu.Step(); !errors.As(err, &unknown) || unknown.Opcode != opcode || unknown.PC != 0x0200 {
+			t.Errorf("$%02X: got %v with undocumented opcodes off, want an unknown opcode error at $0200", opcode, err)
+		}
+
+		// Turned back on they decode again
+		cpu.SetUndocumented(true)
+		step(t, cpu)
+		if pc := cpu.State().PC; pc == 0x0200 {
+			t.Errorf("$%02X: did not run after turning undocumented opcodes back on", opcode)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"6502emulator/emulator"
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// The GDB remote serial protocol, see https://sourceware.org/gdb/onlinedocs/gdb/Remote-Protocol.html.
+// The registers are sent in the order of the target description: A, X, Y, P, SP and the 16 bit PC, little endian.
+
+const gdbTargetXML = `<?xml version="1.0"?>
+<!DOCTYPE target SYSTEM "gdb-target.dtd">
+<target version="1.0">
+  <feature name="org.6502emulator.cpu">
+    <flags id="status" size="1">
+      <field name="C" start="0" end="0"/>
+      <field name="Z" start="1" end="1"/>
+      <field name="I" start="2" end="2"/>
+      <field name="D" start="3" end="3"/>
+      <field name="B" start="4" end="4"/>
+      <field name="V" start="6" end="6"/>
+      <field name="N" start="7" end="7"/>
+    </flags>
+    <reg name="a" bitsize="8" regnum="0" type="uint8"/>
+    <reg name="x" bitsize="8" regnum="1" type="uint8"/>
+    <reg name="y" bitsize="8" regnum="2" type="uint8"/>
+    <reg name="p" bitsize="8" regnum="3" type="status"/>
+    <reg name="sp" bitsize="8" regnum="4" type="uint8"/>
+    <reg name="pc" bitsize="16" regnum="5" type="code_ptr"/>
+  </feature>
+</target>
+`
+
+// The signals reported in stop replies
+const (
+	gdbSIGINT  = 2
+	gdbSIGTRAP = 5
+)
+
+type gdbPacket struct {
+	data  string
+	valid bool // Whether the checksum matched
+}
+
+// gdbServer serves one GDB connection.
+type gdbServer struct {
+	cpu *emulator.CPU
+	bus *emulator.Bus
+
+	conn       net.Conn
+	packets    chan gdbPacket
+	interrupts chan struct{} // Receives the ctrl-C GDB sends while the CPU runs
+	closed     chan struct{} // Closed when the connection is, so a running CPU can be stopped
+	noAck      bool
+}
+
+// newGDBServer starts receiving packets from conn, serve handles them.
+func newGDBServer(cpu *emulator.CPU, bus *emulator.Bus, conn net.Conn) *gdbServer {
+	s := &gdbServer{
+		cpu:        cpu,
+		bus:        bus,
+		conn:       conn,
+		packets:    make(chan gdbPacket),
+		interrupts: make(chan struct{}, 1),
+		closed:     make(chan struct{}),
+	}
+	go s.receive()
+
+	return s
+}
+
+// serveGDB waits for GDB to connect on address and serves it, until GDB kills the target.
+// After a detach it waits for the next connection.
+func serveGDB(cpu *emulator.CPU, bus *emulator.Bus, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	for {
+		fmt.Fprintf(os.Stderr, "waiting for gdb on %s\n", listener.Addr())
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		killed := newGDBServer(cpu, bus, conn).serve()
+		conn.Close()
+		if killed {
+			return nil
+		}
+	}
+}
+
+// receive reads packets from the connection until it is closed.
+func (s *gdbServer) receive() {
+	defer close(s.packets)
+	defer close(s.closed)
+
+	reader := bufio.NewReader(s.conn)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+
+		switch b {
+		case 0x03:
+			select {
+			case s.interrupts <- struct{}{}:
+			default:
+			}
+		case '$':
+			data, err := reader.ReadString('#')
+			if err != nil {
+				return
+			}
+			data = strings.TrimSuffix(data, "#")
+
+			var checksum [2]byte
+			if _, err := io.ReadFull(reader, checksum[:]); err != nil {
+				return
+			}
+			want, err := strconv.ParseUint(string(checksum[:]), 16, 8)
+
+			// A ctrl-C sent before this packet arrived while the CPU wasn't running, drop it so it doesn't stop
+			// the next c. One sent after it, like right after a c, is kept.
+			select {
+			case <-s.interrupts:
+			default:
+			}
+
+			s.packets <- gdbPacket{data: data, valid: err == nil && uint8(want) == gdbChecksum(data)}
+		}
+		// The acknowledgements GDB sends are ignored
+	}
+}
+
+// serve handles packets until the connection is closed, it returns true if GDB killed the target.
+func (s *gdbServer) serve() bool {
+	for packet := range s.packets {
+		if !packet.valid {
+			if !s.noAck {
+				s.conn.Write([]byte("-"))
+			}
+			continue
+		}
+		if !s.noAck {
+			s.conn.Write([]byte("+"))
+		}
+
+		switch packet.data {
+		case "k":
+			return true
+		case "D":
+			s.reply("OK")
+			return false
+		}
+
+		s.reply(s.handle(packet.data))
+	}
+
+	return false
+}
+
+func (s *gdbServer) reply(data string) {
+	fmt.Fprintf(s.conn, "$%s#%02x", data, gdbChecksum(data))
+}
+
+func gdbChecksum(data string) uint8 {
+	var sum uint8
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+
+	return sum
+}
+
+// handle answers a packet, an empty reply tells GDB the packet isn't supported.
+func (s *gdbServer) handle(packet string) string {
+	switch {
+	case packet == "?":
+		return fmt.Sprintf("S%02x", gdbSIGTRAP)
+	case strings.HasPrefix(packet, "qSupported"):
+		return "PacketSize=4000;qXfer:features:read+;QStartNoAckMode+;swbreak+"
+	case packet == "QStartNoAckMode":
+		s.noAck = true
+		return "OK"
+	case strings.HasPrefix(packet, "qXfer:features:read:target.xml:"):
+		return s.readTargetXML(strings.TrimPrefix(packet, "qXfer:features:read:target.xml:"))
+	case packet == "qAttached":
+		return "1"
+	case strings.HasPrefix(packet, "H"):
+		// There is only one thread
+		return "OK"
+	case packet == "g":
+		return s.readRegisters()
+	case strings.HasPrefix(packet, "G"):
+		return s.writeRegisters(packet[1:])
+	case strings.HasPrefix(packet, "m"):
+		return s.readMemory(packet[1:])
+	case strings.HasPrefix(packet, "M"):
+		return s.writeMemory(packet[1:])
+	case strings.HasPrefix(packet, "Z"), strings.HasPrefix(packet, "z"):
+		return s.breakpoint(packet[0] == 'Z', packet[1:])
+	case strings.HasPrefix(packet, "c"):
+		return s.resume(packet[1:], false)
+	case strings.HasPrefix(packet, "s"):
+		return s.resume(packet[1:], true)
+	}
+
+	return ""
+}
+
+// readTargetXML answers a qXfer read of the target description, args is "offset,length".
+func (s *gdbServer) readTargetXML(args string) string {
+	offset, length, ok := parseGDBRange(args)
+	if !ok {
+		return "E01"
+	}
+
+	if offset >= len(gdbTargetXML) {
+		return "l"
+	}
+
+	end := offset + length
+	if end >= len(gdbTargetXML) {
+		return "l" + gdbTargetXML[offset:]
+	}
+
+	return "m" + gdbTargetXML[offset:end]
+}
+
+func (s *gdbServer) readRegisters() string {
+	state := s.cpu.State()
+	return hex.EncodeToString([]byte{state.A, state.X, state.Y, state.P, state.SP, uint8(state.PC), uint8(state.PC >> 8)})
+}
+
+func (s *gdbServer) writeRegisters(data string) string {
+	registers, err := hex.DecodeString(data)
+	if err != nil || len(registers) != 7 {
+		return "E01"
+	}
+
+	state := s.cpu.State()
+	state.A = registers[0]
+	state.X = registers[1]
+	state.Y = registers[2]
+	state.P = registers[3]
+	state.SP = registers[4]
+	state.PC = uint16(registers[5]) | uint16(registers[6])<<8
+	s.cpu.SetState(state)
+
+	return "OK"
+}
+
+// readMemory answers an m packet, "addr,length".
+func (s *gdbServer) readMemory(args string) string {
+	address, length, ok := parseGDBRange(args)
+	if !ok {
+		return "E01"
+	}
+
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = s.bus.Peek(uint16(address + i))
+	}
+
+	return hex.EncodeToString(data)
+}
+
+// writeMemory answers an M packet, "addr,length:data".
+func (s *gdbServer) writeMemory(args string) string {
+	header, encoded, found := strings.Cut(args, ":")
+	address, length, ok := parseGDBRange(header)
+	data, err := hex.DecodeString(encoded)
+	if !found || !ok || err != nil || len(data) != length {
+		return "E01"
+	}
+
+	for i, b := range data {
+		s.bus.Poke(uint16(address+i), b)
+	}
+
+	return "OK"
+}
+
+// breakpoint answers a Z or z packet, "type,addr,kind". For watchpoints kind is the number of bytes watched.
+func (s *gdbServer) breakpoint(insert bool, args string) string {
+	fields := strings.Split(args, ",")
+	if len(fields) < 3 {
+		return "E01"
+	}
+
+	address, length, ok := parseGDBRange(fields[1] + "," + fields[2])
+	if !ok {
+		return "E01"
+	}
+
+	var watch emulator.WatchKind
+	switch fields[0] {
+	case "0", "1":
+		if insert {
+			s.cpu.AddBreakpoint(uint16(address))
+		} else {
+			s.cpu.RemoveBreakpoint(uint16(address))
+		}
+		return "OK"
+	case "2":
+		watch = emulator.WatchWrite
+	case "3":
+		watch = emulator.WatchRead
+	case "4":
+		watch = emulator.WatchAccess
+	default:
+		return ""
+	}
+
+	for i := 0; i < length; i++ {
+		if insert {
+			s.cpu.AddWatchpoint(uint16(address+i), watch)
+		} else {
+			s.cpu.RemoveWatchpoint(uint16(address+i), watch)
+		}
+	}
+
+	return "OK"
+}
+
+// resume answers a c or s packet, args is an optional address to resume at.
+// The CPU runs, for a single instruction if step is set, until it stops or GDB interrupts it,
+// and the reply tells GDB why it stopped.
+func (s *gdbServer) resume(args string, step bool) string {
+	if args != "" {
+		address, err := strconv.ParseUint(args, 16, 16)
+		if err != nil {
+			return "E01"
+		}
+
+		state := s.cpu.State()
+		state.PC = uint16(address)
+		s.cpu.SetState(state)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type result struct {
+		reason emulator.StopReason
+		err    error
+	}
+	done := make(chan result)
+	go func() {
+		var reason emulator.StopReason
+		var err error
+		if step {
+			reason, err = s.cpu.RunInstructions(ctx, 1)
+		} else {
+			reason, err = s.cpu.Run(ctx)
+		}
+		done <- result{reason, err}
+	}()
+
+	var stopped result
+	select {
+	case stopped = <-done:
+	case <-s.interrupts:
+		cancel()
+		stopped = <-done
+	case <-s.closed:
+		// GDB is gone, there is no one to reply to
+		cancel()
+		<-done
+		return ""
+	}
+
+	switch stopped.reason {
+	case emulator.StopCancelled:
+		return fmt.Sprintf("S%02x", gdbSIGINT)
+	case emulator.StopBreakpoint:
+		// A step that lands on a breakpoint is still just a completed step
+		if !step {
+			return fmt.Sprintf("T%02xswbreak:;", gdbSIGTRAP)
+		}
+	case emulator.StopWatchpoint:
+		address, kind := s.cpu.LastWatchpoint()
+		watch := "watch"
+		switch kind {
+		case emulator.WatchRead:
+			watch = "rwatch"
+		case emulator.WatchAccess:
+			watch = "awatch"
+		}
+		return fmt.Sprintf("T%02x%s:%04x;", gdbSIGTRAP, watch, address)
+	case emulator.StopError:
+		fmt.Fprintln(os.Stderr, stopped.err)
+	}
+
+	return fmt.Sprintf("S%02x", gdbSIGTRAP)
+}
+
+// parseGDBRange parses the "addr,length" of many packets, both are hex.
+func parseGDBRange(args string) (int, int, bool) {
+	first, second, found := strings.Cut(args, ",")
+	if !found {
+		return 0, 0, false
+	}
+
+	a, err := strconv.ParseUint(first, 16, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	// No request ever needs to cover more than the address space
+	b, err := strconv.ParseUint(second, 16, 32)
+	if err != nil || b > 0x10000 {
+		return 0, 0, false
+	}
+
+	return int(a), int(b), true
+}
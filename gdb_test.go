@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"6502emulator/emulator"
+)
+
+// gdbClient talks to a gdbServer over a pipe, the way GDB does.
+type gdbClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newGDBTest(t *testing.T) (*gdbClient, *emulator.CPU, *emulator.Bus, chan bool) {
+	t.Helper()
+
+	bus := &emulator.Bus{}
+	bus.AddMemory(emulator.NewRAM(0x1000, 0))
+	for i, b := range []uint8{
+		0xE8,             // $0200 INX
+		0x4C, 0x00, 0x02, // $0201 JMP $0200
+	} {
+		bus.Write(0x0200+uint16(i), b)
+	}
+
+	cpu := emulator.NewCPU()
+	cpu.ConnectBus(bus)
+	cpu.SetState(emulator.State{PC: 0x0200, SP: 0xFF, P: 0x20})
+
+	client, server := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+
+	killed := make(chan bool, 1)
+	go func() {
+		killed <- newGDBServer(cpu, bus, server).serve()
+	}()
+
+	return &gdbClient{t, client, bufio.NewReader(client)}, cpu, bus, killed
+}
+
+// send sends a packet and waits for the server to acknowledge it.
+func (c *gdbClient) send(packet string) {
+	c.t.Helper()
+
+	fmt.Fprintf(c.conn, "$%s#%02x", packet, gdbChecksum(packet))
+	if ack, err := c.reader.ReadByte(); err != nil || ack != '+' {
+		c.t.Fatalf("%s: got %q, %v, want an acknowledgement", packet, ack, err)
+	}
+}
+
+// receive reads a reply and checks its checksum.
+func (c *gdbClient) receive() string {
+	c.t.Helper()
+
+	if start, err := c.reader.ReadByte(); err != nil || start != '$' {
+		c.t.Fatalf("got %q, %v, want the start of a reply", start, err)
+	}
+	data, err := c.reader.ReadString('#')
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	data = strings.TrimSuffix(data, "#")
+
+	var checksum [2]byte
+	if _, err := c.reader.Read(checksum[:1]); err != nil {
+		c.t.Fatal(err)
+	}
+	if _, err := c.reader.Read(checksum[1:]); err != nil {
+		c.t.Fatal(err)
+	}
+	if want := fmt.Sprintf("%02x", gdbChecksum(data)); string(checksum[:]) != want {
+		c.t.Errorf("%q: got checksum %s, want %s", data, checksum[:], want)
+	}
+
+	return data
+}
+
+// exchange sends a packet and returns the reply.
+func (c *gdbClient) exchange(packet string) string {
+	c.t.Helper()
+
+	c.send(packet)
+	return c.receive()
+}
+
+func TestGDBPackets(t *testing.T) {
+	client, cpu, bus, _ := newGDBTest(t)
+
+	io := emulator.NewIO(0xF000, nil, nil)
+	io.(emulator.InputPort).Input(0x41)
+	bus.AddMemory(io)
+
+	tests := []struct {
+		packet string
+		want   string
+	}{
+		{"g", "00000020ff0002"},
+		{"G01020320fe0002", "OK"},
+		{"g", "01020320fe0002"},
+		{"m200,4", "e84c0002"},
+		{"M300,2:abcd", "OK"},
+		{"m300,2", "abcd"},
+		// Reading memory doesn't take the input from the port
+		{"mf000,1", "41"},
+		{"mf000,1", "41"},
+		{"Z0,201,1", "OK"},
+		{"c", "T05swbreak:;"},
+		{"g", "01030320fe0102"},
+		{"z0,201,1", "OK"},
+		{"s", "S05"},
+		{"g", "01030320fe0002"},
+		{"m200,zz", "E01"},
+	}
+
+	for _, test := range tests {
+		if got := client.exchange(test.packet); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.packet, got, test.want)
+		}
+	}
+
+	if state := cpu.State(); state.PC != 0x0200 || state.X != 0x03 {
+		t.Errorf("got pc $%04X X=%d, want $0200 X=3", state.PC, state.X)
+	}
+}
+
+func TestGDBInterrupt(t *testing.T) {
+	client, _, _, _ := newGDBTest(t)
+
+	// The loop never stops by itself
+	client.send("c")
+	client.conn.Write([]byte{0x03})
+	if got := client.receive(); got != "S02" {
+		t.Errorf("got %q after ctrl-C, want S02", got)
+	}
+
+	if got := client.exchange("s"); got != "S05" {
+		t.Errorf("got %q stepping after ctrl-C, want S05", got)
+	}
+}
+
+func TestGDBDisconnectWhileRunning(t *testing.T) {
+	client, _, _, killed := newGDBTest(t)
+
+	client.send("c")
+	client.conn.Close()
+
+	select {
+	case k := <-killed:
+		if k {
+			t.Error("a disconnect killed the target")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server is still running after GDB disconnected")
+	}
+}
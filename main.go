@@ -2,10 +2,15 @@ package main
 
 import (
 	"6502emulator/emulator"
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
-	"time"
 )
 
 const (
@@ -25,27 +30,75 @@ const (
 	MAX_ROM_SIZE = (1 << 15) - (1 << 8)
 )
 
+var clockFlag = flag.String("clock", "unlimited", `clock speed: a frequency such as "1MHz", "1.79MHz" or "500kHz", "step" to run one instruction per line typed on the terminal, or "unlimited"`)
+var recordFlag = flag.String("record", "", "log the input and interrupts to this file, with the cycle they arrived at")
+var replayFlag = flag.String("replay", "", "feed the input and interrupts logged by --record back at the same cycles, instead of reading stdin")
+var debugFlag = flag.Bool("debug", false, "start the debugger, it reads its commands from the terminal")
+var symbolsFlag = flag.String("symbols", "", `labels for the debugger, one "name = $addr" per line`)
+var gdbFlag = flag.String("gdb", "", `serve the GDB remote protocol on this address, like "localhost:1234", instead of running the program`)
+
 func main() {
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: 6502emulator [flags] <rom>")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	clock, err := parseClock(*clockFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	cpu := emulator.NewCPU()
 	bus := &emulator.Bus{}
 
 	bus.AddMemory(emulator.NewRAM(RAM_SIZE, RAM_OFFSET))
 
-	stdInChan, _ := emulator.InOutFromFile(os.Stdin, emulator.Read)
 	_, stdOutChan := emulator.InOutFromFile(os.Stderr, emulator.Write)
 
-	bus.AddMemory(emulator.NewIO(StdInOut, stdInChan, stdOutChan))
-
-	cpu.Reset()
+	// The input arrives through ConnectInput, so it can be recorded and replayed
+	stdInOut := emulator.NewIO(StdInOut, nil, stdOutChan)
+	bus.AddMemory(stdInOut)
 
 	cpu.ConnectBus(bus)
 
+	input := make(chan uint8)
 	interupt := make(chan struct{})
 
+	cpu.ConnectInput(input, stdInOut.(emulator.InputPort))
 	cpu.ConnectInterrupt(interupt)
 
+	if *recordFlag != "" {
+		file, err := os.Create(*recordFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer file.Close()
+
+		cpu.Record(file)
+	}
+
+	if *replayFlag != "" {
+		file, err := os.Open(*replayFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		err = cpu.Replay(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
 	// Load the ROM
-	data, err := os.ReadFile(os.Args[1])
+	data, err := os.ReadFile(flag.Arg(0))
 	if err != nil {
 		panic(err)
 	}
@@ -58,28 +111,125 @@ func main() {
 
 	bus.AddMemory(emulator.NewROM(data, uint16(offset)))
 
-	clock := make(chan time.Time)
-	close(clock)
-	cpu.ConnectClock(clock)
+	cpu.SetClock(clock)
+
+	if *replayFlag == "" {
+		stdInChan, _ := emulator.InOutFromFile(os.Stdin, emulator.Read)
 
-	go func() {
-		for {
-			<-stdInChan
-			interupt <- struct{}{}
+		// Every byte typed raises an interrupt
+		go func() {
+			for data := range stdInChan {
+				input <- data
+				interupt <- struct{}{}
+			}
+		}()
+	}
+
+	if *debugFlag {
+		debug(cpu, bus)
+		return
+	}
+
+	if *gdbFlag != "" {
+		cpu.PowerOn()
+		if err := serveGDB(cpu, bus, *gdbFlag); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
-	}()
+		return
+	}
 
-	sig := make(chan os.Signal, 1)
-	signal.Notify(sig,
+	ctx, stop := signal.NotifyContext(context.Background(),
 		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT,
 	)
-	go func() {
-		<-sig
-		os.Exit(0)
-	}()
+	defer stop()
+
+	cpu.PowerOn()
+
+	reason, err := cpu.Run(ctx)
+	if reason != emulator.StopCancelled && err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// debug powers on the CPU and hands it to the debugger.
+func debug(cpu *emulator.CPU, bus *emulator.Bus) {
+	symbols := map[string]uint16{}
+	if *symbolsFlag != "" {
+		file, err := os.Open(*symbolsFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		symbols, err = loadSymbols(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	// stdin belongs to the emulated program, so the commands come from the terminal
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "debugger: %v\n", err)
+		os.Exit(2)
+	}
+	defer tty.Close()
+
+	cpu.PowerOn()
+	newDebugger(cpu, bus, symbols, tty, tty).run()
+}
+
+// parseClock parses the value of the --clock flag.
+func parseClock(value string) (emulator.Clock, error) {
+	switch strings.ToLower(value) {
+	case "unlimited", "":
+		return nil, nil
+	case "step":
+		// stdin belongs to the emulated program, so the steps come from the terminal
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return nil, fmt.Errorf("step clock: %w", err)
+		}
+
+		steps := make(chan struct{})
+		go func() {
+			scanner := bufio.NewScanner(tty)
+			for scanner.Scan() {
+				steps <- struct{}{}
+			}
+		}()
+
+		return emulator.StepClock{C: steps}, nil
+	}
+
+	number := strings.ToLower(value)
+	multiplier := 1.0
+	for _, unit := range []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"mhz", 1e6},
+		{"khz", 1e3},
+		{"hz", 1},
+	} {
+		if strings.HasSuffix(number, unit.suffix) {
+			number = strings.TrimSpace(strings.TrimSuffix(number, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	frequency, err := strconv.ParseFloat(number, 64)
+	if err != nil || frequency <= 0 {
+		return nil, fmt.Errorf("invalid clock %q", value)
+	}
 
-	cpu.Start()
+	return emulator.NewRealTimeClock(frequency * multiplier), nil
 }
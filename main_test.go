@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"6502emulator/emulator"
+)
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		value     string
+		frequency float64 // 0 means no clock
+	}{
+		{"unlimited", 0},
+		{"Unlimited", 0},
+		{"", 0},
+		{"1000", 1000},
+		{"2.5", 2.5},
+		{"1mhz", 1e6},
+		{"1.79MHz", 1.79e6},
+		{"500khz", 500e3},
+		{"500 kHz", 500e3},
+		{"60hz", 60},
+	}
+
+	for _, test := range tests {
+		clock, err := parseClock(test.value)
+		if err != nil {
+			t.Errorf("%q: %v", test.value, err)
+			continue
+		}
+
+		if test.frequency == 0 {
+			if clock != nil {
+				t.Errorf("%q: got %T, want no clock", test.value, clock)
+			}
+			continue
+		}
+
+		realTime, ok := clock.(*emulator.RealTimeClock)
+		if !ok {
+			t.Errorf("%q: got %T, want a real time clock", test.value, clock)
+			continue
+		}
+		if realTime.Frequency != test.frequency {
+			t.Errorf("%q: got %v Hz, want %v Hz", test.value, realTime.Frequency, test.frequency)
+		}
+	}
+
+	for _, value := range []string{"fast", "mhz", "1ghz", "0", "-1mhz", "1 2"} {
+		if _, err := parseClock(value); err == nil {
+			t.Errorf("%q: got no error", value)
+		}
+	}
+}